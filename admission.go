@@ -0,0 +1,184 @@
+package HopeIM
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"golang.org/x/time/rate"
+)
+
+var (
+	ErrAddressDenied           = errors.New("err:address is denied")
+	ErrTooManyConnections      = errors.New("err:too many connections")
+	ErrTooManyConnectionsPerIP = errors.New("err:too many connections from the same ip")
+	ErrAcceptRateLimited       = errors.New("err:accept rate limited")
+)
+
+var admissionRejected = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "hopeim",
+	Name:      "admission_rejected_total",
+	Help:      "number of connections rejected by the admission control",
+}, []string{"reason"})
+
+// Admission 连接准入控制，在Acceptor之前执行
+type Admission interface {
+	// Admit 判断是否接收一个来自remoteAddr的新连接，拒绝时返回的error作为关闭原因
+	Admit(remoteAddr string) error
+	// Release 被接收的连接关闭时调用，与Admit成功的调用一一对应
+	Release(remoteAddr string)
+}
+
+// AdmissionOptions 准入控制的配置，零值表示不限制
+type AdmissionOptions struct {
+	// MaxConnections 全局最大连接数
+	MaxConnections int
+	// MaxConnectionsPerIP 单个IP的最大连接数
+	MaxConnectionsPerIP int
+	// AcceptRate 每秒最多接收的新连接数，AcceptBurst为令牌桶的容量
+	AcceptRate  float64
+	AcceptBurst int
+	// Allow 不为空时只接收这些CIDR中的地址
+	Allow []string
+	// Deny 拒绝这些CIDR中的地址，优先于Allow
+	Deny []string
+}
+
+// AdmissionControl Admission的默认实现
+type AdmissionControl struct {
+	sync.Mutex
+	opts    AdmissionOptions
+	allow   []*net.IPNet
+	deny    []*net.IPNet
+	limiter *rate.Limiter
+	total   int
+	perIP   map[string]int
+}
+
+// NewAdmission NewAdmission
+func NewAdmission(opts AdmissionOptions) (*AdmissionControl, error) {
+	allow, err := parseCIDRs(opts.Allow)
+	if err != nil {
+		return nil, err
+	}
+	deny, err := parseCIDRs(opts.Deny)
+	if err != nil {
+		return nil, err
+	}
+	a := &AdmissionControl{
+		opts:  opts,
+		allow: allow,
+		deny:  deny,
+		perIP: make(map[string]int),
+	}
+	if opts.AcceptRate > 0 {
+		burst := opts.AcceptBurst
+		if burst < 1 {
+			burst = 1
+		}
+		a.limiter = rate.NewLimiter(rate.Limit(opts.AcceptRate), burst)
+	}
+	return a, nil
+}
+
+// Admit Admit，先检查连接数的限制，被拒绝的连接不会占用接收速率的令牌
+func (a *AdmissionControl) Admit(remoteAddr string) error {
+	ip := remoteIP(remoteAddr)
+	if !a.permit(net.ParseIP(ip)) {
+		return reject("denied", ErrAddressDenied)
+	}
+	a.Lock()
+	defer a.Unlock()
+	if a.opts.MaxConnections > 0 && a.total >= a.opts.MaxConnections {
+		return reject("max_connections", ErrTooManyConnections)
+	}
+	if a.opts.MaxConnectionsPerIP > 0 && a.perIP[ip] >= a.opts.MaxConnectionsPerIP {
+		return reject("per_ip", ErrTooManyConnectionsPerIP)
+	}
+	if a.limiter != nil && !a.limiter.Allow() {
+		return reject("rate", ErrAcceptRateLimited)
+	}
+	a.total++
+	a.perIP[ip]++
+	return nil
+}
+
+// Release Release
+func (a *AdmissionControl) Release(remoteAddr string) {
+	ip := remoteIP(remoteAddr)
+	a.Lock()
+	defer a.Unlock()
+	if a.total > 0 {
+		a.total--
+	}
+	if n := a.perIP[ip]; n > 1 {
+		a.perIP[ip] = n - 1
+	} else {
+		delete(a.perIP, ip)
+	}
+}
+
+// Count 当前被接收的连接数
+func (a *AdmissionControl) Count() int {
+	a.Lock()
+	defer a.Unlock()
+	return a.total
+}
+
+func (a *AdmissionControl) permit(ip net.IP) bool {
+	// 无法解析的地址不能匹配任何规则，配置了Allow或者Deny时直接拒绝
+	if ip == nil {
+		return len(a.allow) == 0 && len(a.deny) == 0
+	}
+	for _, n := range a.deny {
+		if n.Contains(ip) {
+			return false
+		}
+	}
+	if len(a.allow) == 0 {
+		return true
+	}
+	for _, n := range a.allow {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func reject(reason string, err error) error {
+	admissionRejected.WithLabelValues(reason).Inc()
+	return err
+}
+
+func parseCIDRs(cidrs []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			// 兼容单个IP
+			ip := net.ParseIP(cidr)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid cidr %s: %w", cidr, err)
+			}
+			bits := 8 * len(ip.To16())
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			n = &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}
+		}
+		nets = append(nets, n)
+	}
+	return nets, nil
+}
+
+func remoteIP(remoteAddr string) string {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return remoteAddr
+	}
+	return host
+}
@@ -0,0 +1,78 @@
+package HopeIM
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdmission_Limits(t *testing.T) {
+	a, err := NewAdmission(AdmissionOptions{
+		MaxConnections:      3,
+		MaxConnectionsPerIP: 2,
+	})
+	assert.Nil(t, err)
+	before := testutil.ToFloat64(admissionRejected.WithLabelValues("per_ip"))
+
+	assert.Nil(t, a.Admit("10.0.0.1:1000"))
+	assert.Nil(t, a.Admit("10.0.0.1:1001"))
+	assert.Equal(t, ErrTooManyConnectionsPerIP, a.Admit("10.0.0.1:1002"))
+	assert.Equal(t, before+1, testutil.ToFloat64(admissionRejected.WithLabelValues("per_ip")))
+
+	assert.Nil(t, a.Admit("10.0.0.2:1000"))
+	assert.Equal(t, ErrTooManyConnections, a.Admit("10.0.0.3:1000"))
+	assert.Equal(t, 3, a.Count())
+
+	// 释放之后可以重新接收
+	a.Release("10.0.0.1:1000")
+	assert.Nil(t, a.Admit("10.0.0.1:1003"))
+}
+
+func TestAdmission_CIDR(t *testing.T) {
+	_, err := NewAdmission(AdmissionOptions{Deny: []string{"10.0.0.300/8"}})
+	assert.NotNil(t, err)
+
+	a, err := NewAdmission(AdmissionOptions{
+		Allow: []string{"10.0.0.0/8", "192.168.1.1"},
+		Deny:  []string{"10.1.0.0/16"},
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, a.Admit("10.0.0.1:1000"))
+	assert.Nil(t, a.Admit("192.168.1.1:1000"))
+	assert.Equal(t, ErrAddressDenied, a.Admit("10.1.0.1:1000"))
+	assert.Equal(t, ErrAddressDenied, a.Admit("192.168.1.2:1000"))
+
+	// 无法解析的地址同样会被Deny拒绝
+	a, _ = NewAdmission(AdmissionOptions{Deny: []string{"10.1.0.0/16"}})
+	assert.Equal(t, ErrAddressDenied, a.Admit("bad-addr"))
+	a, _ = NewAdmission(AdmissionOptions{})
+	assert.Nil(t, a.Admit("bad-addr"))
+}
+
+func TestAdmission_AcceptRate(t *testing.T) {
+	a, err := NewAdmission(AdmissionOptions{
+		AcceptRate:  0.001,
+		AcceptBurst: 2,
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, a.Admit("10.0.0.1:1000"))
+	assert.Nil(t, a.Admit("10.0.0.1:1001"))
+	assert.Equal(t, ErrAcceptRateLimited, a.Admit("10.0.0.1:1002"))
+}
+
+func TestAdmission_RateAfterLimits(t *testing.T) {
+	a, err := NewAdmission(AdmissionOptions{
+		MaxConnectionsPerIP: 1,
+		AcceptRate:          0.001,
+		AcceptBurst:         2,
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, a.Admit("10.0.0.1:1000"))
+	// 超过单IP限制被拒绝的连接不消耗令牌
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, ErrTooManyConnectionsPerIP, a.Admit("10.0.0.1:1001"))
+	}
+	assert.Nil(t, a.Admit("10.0.0.2:1000"))
+	assert.Equal(t, ErrAcceptRateLimited, a.Admit("10.0.0.3:1000"))
+}
@@ -0,0 +1,86 @@
+package HopeIM
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"github.com/sjmshsh/HopeIM/wire/pkt"
+)
+
+// IndexAccount ChannelMap中按account建立的索引
+const IndexAccount = "account"
+
+// Attributes Channel的属性，由Acceptor在Accept时填充。
+// 类型化的字段在Channel创建之后只读；自定义属性通过Set/Get读写，是并发安全的
+type Attributes struct {
+	Account   string
+	App       string
+	Device    string
+	LoginTime time.Time
+	RemoteIP  string
+	// ContentType 登录时协商的消息体编码，网关主动推送的消息使用它编码
+	ContentType pkt.ContentType
+
+	mu     sync.RWMutex
+	custom map[string]string
+}
+
+// NewAttributes NewAttributes
+func NewAttributes() *Attributes {
+	return &Attributes{}
+}
+
+// ResolveAttributes 补全Acceptor返回的属性，LoginTime与RemoteIP为空时使用当前时间与连接的远端地址
+func ResolveAttributes(attrs *Attributes, conn net.Conn) *Attributes {
+	if attrs == nil {
+		attrs = NewAttributes()
+	}
+	if attrs.LoginTime.IsZero() {
+		attrs.LoginTime = time.Now()
+	}
+	if attrs.RemoteIP == "" && conn.RemoteAddr() != nil {
+		host, _, err := net.SplitHostPort(conn.RemoteAddr().String())
+		if err != nil {
+			host = conn.RemoteAddr().String()
+		}
+		attrs.RemoteIP = host
+	}
+	return attrs
+}
+
+// Set 设置一个自定义属性
+func (a *Attributes) Set(key, value string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.custom == nil {
+		a.custom = make(map[string]string)
+	}
+	a.custom[key] = value
+}
+
+// Get 读取一个自定义属性
+func (a *Attributes) Get(key string) (string, bool) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	val, ok := a.custom[key]
+	return val, ok
+}
+
+// Delete 删除一个自定义属性
+func (a *Attributes) Delete(key string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	delete(a.custom, key)
+}
+
+// Range 遍历自定义属性，f返回false时停止
+func (a *Attributes) Range(f func(key, value string) bool) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	for k, v := range a.custom {
+		if !f(k, v) {
+			return
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package HopeIM
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type addrConn struct {
+	net.Conn
+	addr net.Addr
+}
+
+func (c *addrConn) RemoteAddr() net.Addr { return c.addr }
+
+func TestResolveAttributes(t *testing.T) {
+	conn := &addrConn{addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 8000}}
+
+	attrs := ResolveAttributes(nil, conn)
+	assert.Equal(t, "10.0.0.1", attrs.RemoteIP)
+	assert.False(t, attrs.LoginTime.IsZero())
+
+	// Acceptor填充的属性不会被覆盖
+	login := time.Now().Add(-time.Minute)
+	attrs = ResolveAttributes(&Attributes{Account: "test1", RemoteIP: "1.1.1.1", LoginTime: login}, conn)
+	assert.Equal(t, "test1", attrs.Account)
+	assert.Equal(t, "1.1.1.1", attrs.RemoteIP)
+	assert.Equal(t, login, attrs.LoginTime)
+}
+
+func TestChannel_Attributes(t *testing.T) {
+	ch := NewChannel("ch1", newStalledConn())
+	defer ch.Close()
+	assert.NotNil(t, ch.Attributes())
+
+	attrs := NewAttributes()
+	attrs.Account = "test1"
+	ch.SetAttributes(attrs)
+	ch.SetAttributes(nil)
+	assert.Equal(t, "test1", ch.Attributes().Account)
+
+	ch.Attributes().Set("region", "cn")
+	val, ok := ch.Attributes().Get("region")
+	assert.True(t, ok)
+	assert.Equal(t, "cn", val)
+	ch.Attributes().Delete("region")
+	_, ok = ch.Attributes().Get("region")
+	assert.False(t, ok)
+}
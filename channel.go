@@ -5,32 +5,139 @@ import (
 	"fmt"
 	"github.com/sjmshsh/HopeIM/logger"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
+// OverflowPolicy 写队列满时的处理策略
+type OverflowPolicy int
+
+const (
+	// OverflowBlock 阻塞等待队列空闲，超过Timeout之后返回ErrWriteTimeout
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropOldest 丢弃队列中最早的一条消息，再写入新消息
+	OverflowDropOldest
+	// OverflowDropNewest 直接丢弃当前要写入的消息
+	OverflowDropNewest
+	// OverflowDisconnect 认为对端是慢消费者，直接断开连接
+	OverflowDisconnect
+)
+
+func (p OverflowPolicy) String() string {
+	switch p {
+	case OverflowBlock:
+		return "block"
+	case OverflowDropOldest:
+		return "drop_oldest"
+	case OverflowDropNewest:
+		return "drop_newest"
+	case OverflowDisconnect:
+		return "disconnect"
+	}
+	return fmt.Sprintf("OverflowPolicy(%d)", int(p))
+}
+
+// ParseOverflowPolicy 从配置中解析OverflowPolicy，空字符串返回OverflowBlock
+func ParseOverflowPolicy(s string) (OverflowPolicy, error) {
+	switch s {
+	case "", "block":
+		return OverflowBlock, nil
+	case "drop_oldest":
+		return OverflowDropOldest, nil
+	case "drop_newest":
+		return OverflowDropNewest, nil
+	case "disconnect":
+		return OverflowDisconnect, nil
+	}
+	return OverflowBlock, fmt.Errorf("unknown overflow policy %s", s)
+}
+
+var (
+	ErrWriteTimeout = errors.New("err:channel write queue timeout")
+	ErrQueueFull    = errors.New("err:channel write queue is full")
+	ErrSlowConsumer = errors.New("err:slow consumer disconnected")
+)
+
+// WriteQueueOptions 定义Channel的发送队列
+type WriteQueueOptions struct {
+	// Size 队列长度
+	Size int
+	// Policy 队列满时的处理策略
+	Policy OverflowPolicy
+	// Timeout 只在OverflowBlock策略下生效
+	Timeout time.Duration
+}
+
+// DefaultWriteQueueOptions 默认的发送队列配置
+func DefaultWriteQueueOptions() WriteQueueOptions {
+	return WriteQueueOptions{
+		Size:    DefaultWriteQueueSize,
+		Policy:  OverflowBlock,
+		Timeout: DefaultWriteQueueTimeout,
+	}
+}
+
+// QueueStats 发送队列的计数器
+type QueueStats struct {
+	// Depth 当前队列中等待发送的消息数
+	Depth int
+	// Capacity 队列容量
+	Capacity int
+	// Enqueued 成功进入队列的消息总数
+	Enqueued uint64
+	// Dropped 被丢弃的消息总数，包括写超时
+	Dropped uint64
+	// Overflows 队列满的次数
+	Overflows uint64
+}
+
 type ChannelImpl struct {
 	sync.Mutex
 	id string
 	Conn
 	writechan chan []byte
+	queue     WriteQueueOptions
 	once      sync.Once
 	writeWait time.Duration
 	readwait  time.Duration
 	closed    *Event
+	// writeDone writeloop退出时关闭
+	writeDone chan struct{}
+	reader    ReadDispatcher
+	attrs     *Attributes
+
+	enqueued  uint64
+	dropped   uint64
+	overflows uint64
 }
 
 func NewChannel(id string, conn Conn) Channel {
+	return NewChannelWithOptions(id, conn, DefaultWriteQueueOptions())
+}
+
+// NewChannelWithOptions 创建一个Channel，并指定发送队列的长度及溢出策略
+func NewChannelWithOptions(id string, conn Conn, queue WriteQueueOptions) Channel {
 	log := logger.WithFields(logger.Fields{
 		"module": "channel",
 		"id":     id,
 	})
+	if queue.Size <= 0 {
+		queue.Size = DefaultWriteQueueSize
+	}
+	if queue.Policy == OverflowBlock && queue.Timeout <= 0 {
+		queue.Timeout = DefaultWriteQueueTimeout
+	}
 	ch := &ChannelImpl{
 		id:        id,
 		Conn:      conn,
-		writechan: make(chan []byte, 5),
+		writechan: make(chan []byte, queue.Size),
+		queue:     queue,
 		closed:    NewEvent(),
+		writeDone: make(chan struct{}),
 		writeWait: DefaultWriteWait, //default value
 		readwait:  DefaultReadWait,
+		reader:    GoroutineDispatcher{},
+		attrs:     NewAttributes(),
 	}
 	go func() {
 		err := ch.writeloop()
@@ -42,6 +149,7 @@ func NewChannel(id string, conn Conn) Channel {
 }
 
 func (ch *ChannelImpl) writeloop() error {
+	defer close(ch.writeDone)
 	for {
 		select {
 		case payload := <-ch.writechan:
@@ -62,21 +170,107 @@ func (ch *ChannelImpl) writeloop() error {
 				return err
 			}
 		case <-ch.closed.Done():
-			return nil
+			return ch.flush()
 		}
 	}
 }
 
+// flush 关闭之前在一个写超时内发送队列中剩余的消息，比如下线时的重连通知
+func (ch *ChannelImpl) flush() error {
+	n := len(ch.writechan)
+	if n == 0 {
+		return nil
+	}
+	_ = ch.Conn.SetWriteDeadline(time.Now().Add(ch.writeWait))
+	for i := 0; i < n; i++ {
+		if err := ch.Conn.WriteFrame(OpBinary, <-ch.writechan); err != nil {
+			return err
+		}
+	}
+	return ch.Conn.Flush()
+}
+
 func (ch *ChannelImpl) ID() string { return ch.id }
 
-// Push 异步写数据
+// Attributes 连接的属性
+func (ch *ChannelImpl) Attributes() *Attributes { return ch.attrs }
+
+// SetAttributes SetAttributes
+func (ch *ChannelImpl) SetAttributes(attrs *Attributes) {
+	if attrs == nil {
+		return
+	}
+	ch.attrs = attrs
+}
+
+// Push 异步写数据，队列满时按照OverflowPolicy处理
 func (ch *ChannelImpl) Push(payload []byte) error {
 	if ch.closed.HasFired() {
 		return fmt.Errorf("channel %s has closed", ch.id)
 	}
 	// 异步写
-	ch.writechan <- payload
-	return nil
+	select {
+	case ch.writechan <- payload:
+		atomic.AddUint64(&ch.enqueued, 1)
+		return nil
+	case <-ch.closed.Done():
+		return fmt.Errorf("channel %s has closed", ch.id)
+	default:
+	}
+	atomic.AddUint64(&ch.overflows, 1)
+
+	switch ch.queue.Policy {
+	case OverflowDropOldest:
+		for {
+			select {
+			case ch.writechan <- payload:
+				atomic.AddUint64(&ch.enqueued, 1)
+				return nil
+			default:
+			}
+			select {
+			case <-ch.writechan:
+				atomic.AddUint64(&ch.dropped, 1)
+			default:
+			}
+		}
+	case OverflowDropNewest:
+		atomic.AddUint64(&ch.dropped, 1)
+		return ErrQueueFull
+	case OverflowDisconnect:
+		atomic.AddUint64(&ch.dropped, 1)
+		logger.WithFields(logger.Fields{
+			"module": "channel",
+			"id":     ch.id,
+		}).Warnf("write queue is full(%d), disconnect the slow consumer", ch.queue.Size)
+		// 慢消费者不再发送队列中的消息
+		ch.close(false)
+		return ErrSlowConsumer
+	default:
+		timer := time.NewTimer(ch.queue.Timeout)
+		defer timer.Stop()
+		select {
+		case ch.writechan <- payload:
+			atomic.AddUint64(&ch.enqueued, 1)
+			return nil
+		case <-ch.closed.Done():
+			return fmt.Errorf("channel %s has closed", ch.id)
+		case <-timer.C:
+			atomic.AddUint64(&ch.dropped, 1)
+			return ErrWriteTimeout
+		}
+	}
+}
+
+// QueueStats 返回发送队列的计数器
+func (ch *ChannelImpl) QueueStats() QueueStats {
+	return QueueStats{
+		Depth:     len(ch.writechan),
+		Capacity:  cap(ch.writechan),
+		Enqueued:  atomic.LoadUint64(&ch.enqueued),
+		Dropped:   atomic.LoadUint64(&ch.dropped),
+		Overflows: atomic.LoadUint64(&ch.overflows),
+	}
 }
 
 func (ch *ChannelImpl) WriteFrame(code OpCode, payload []byte) error {
@@ -84,13 +278,27 @@ func (ch *ChannelImpl) WriteFrame(code OpCode, payload []byte) error {
 	return ch.Conn.WriteFrame(code, payload)
 }
 
-// Close 关闭连接
+// Close 关闭连接，关闭之前发送完队列中剩余的消息
 func (ch *ChannelImpl) Close() error {
+	ch.close(true)
+	return nil
+}
+
+func (ch *ChannelImpl) close(flush bool) {
 	ch.once.Do(func() {
-		close(ch.writechan)
+		// writechan不再close，避免与并发的Push产生panic
 		ch.closed.Fire()
+		if flush {
+			// 等待writeloop发送完剩余的消息，对端不读取数据时最多等待一个writeWait
+			timer := time.NewTimer(ch.writeWait)
+			select {
+			case <-ch.writeDone:
+			case <-timer.C:
+			}
+			timer.Stop()
+		}
+		_ = ch.Conn.Close()
 	})
-	return nil
 }
 
 func (ch *ChannelImpl) SetWriteWait(writeWait time.Duration) {
@@ -107,6 +315,14 @@ func (ch *ChannelImpl) SetReadWait(readwait time.Duration) {
 	ch.writeWait = readwait
 }
 
+// SetReadDispatcher 设置Readloop中消息的分发方式，默认每条消息一个goroutine
+func (ch *ChannelImpl) SetReadDispatcher(reader ReadDispatcher) {
+	if reader == nil {
+		return
+	}
+	ch.reader = reader
+}
+
 func (ch *ChannelImpl) Readloop(lst MessageListener) error {
 	ch.Lock()
 	defer ch.Unlock()
@@ -120,21 +336,44 @@ func (ch *ChannelImpl) Readloop(lst MessageListener) error {
 
 		frame, err := ch.ReadFrame()
 		if err != nil {
+			// 超过大小限制的帧，返回之后由server关闭channel
+			if CountLimitExceeded(err) {
+				log.Warn(err)
+			}
 			return err
 		}
 		if frame.GetOpCode() == OpClose {
+			ReleaseFrame(frame)
 			return errors.New("remote side close the channel")
 		}
 		if frame.GetOpCode() == OpPing {
+			ReleaseFrame(frame)
 			log.Trace("recv a ping; resp with a pong")
 			_ = ch.WriteFrame(OpPong, nil)
 			continue
 		}
 		payload := frame.GetPayload()
 		if len(payload) == 0 {
+			ReleaseFrame(frame)
 			continue
 		}
-		// TODO: Optimization point
-		go lst.Receive(ch, payload)
+		var listener = lst
+		if _, ok := frame.(frameReleaser); ok {
+			listener = &releaseListener{MessageListener: lst, frame: frame}
+		}
+		if err = ch.reader.Dispatch(ch, payload, listener); err != nil {
+			return err
+		}
 	}
 }
+
+// releaseListener 在Receive返回之后归还frame的缓冲区，消息可能被异步处理，不能在Readloop中直接归还
+type releaseListener struct {
+	MessageListener
+	frame Frame
+}
+
+func (l *releaseListener) Receive(ag Agent, payload []byte) {
+	defer ReleaseFrame(l.frame)
+	l.MessageListener.Receive(ag, payload)
+}
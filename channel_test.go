@@ -0,0 +1,181 @@
+package HopeIM
+
+import (
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// stalledConn 模拟一个不读取数据的慢消费者
+type stalledConn struct {
+	net.Conn
+	release chan struct{}
+	closed  chan struct{}
+	// written 对端收到的帧数
+	written int32
+}
+
+func newStalledConn() *stalledConn {
+	c1, _ := net.Pipe()
+	return &stalledConn{Conn: c1, release: make(chan struct{}), closed: make(chan struct{})}
+}
+
+func (c *stalledConn) ReadFrame() (Frame, error) {
+	<-c.closed
+	return nil, net.ErrClosed
+}
+
+func (c *stalledConn) WriteFrame(OpCode, []byte) error {
+	select {
+	case <-c.release:
+		atomic.AddInt32(&c.written, 1)
+		return nil
+	case <-c.closed:
+		return net.ErrClosed
+	}
+}
+
+func (c *stalledConn) Flush() error { return nil }
+
+func (c *stalledConn) SetWriteDeadline(time.Time) error { return nil }
+
+func (c *stalledConn) Close() error {
+	select {
+	case <-c.closed:
+	default:
+		close(c.closed)
+	}
+	return nil
+}
+
+func fillQueue(t *testing.T, ch Channel, size int) {
+	// 对端不读取数据，Close最多等待一个writeWait
+	ch.SetWriteWait(time.Millisecond * 100)
+	// 第一条消息会被writeloop取走并阻塞在WriteFrame中
+	assert.Nil(t, ch.Push([]byte{0}))
+	assert.Eventually(t, func() bool { return ch.QueueStats().Depth == 0 }, time.Second, time.Millisecond)
+	for i := 0; i < size; i++ {
+		assert.Nil(t, ch.Push([]byte{byte(i + 1)}))
+	}
+}
+
+func TestChannel_OverflowDropNewest(t *testing.T) {
+	ch := NewChannelWithOptions("test", newStalledConn(), WriteQueueOptions{Size: 2, Policy: OverflowDropNewest})
+	defer ch.Close()
+	fillQueue(t, ch, 2)
+
+	assert.Equal(t, ErrQueueFull, ch.Push([]byte{9}))
+	stats := ch.QueueStats()
+	assert.Equal(t, 2, stats.Depth)
+	assert.Equal(t, 2, stats.Capacity)
+	assert.Equal(t, uint64(3), stats.Enqueued)
+	assert.Equal(t, uint64(1), stats.Dropped)
+	assert.Equal(t, uint64(1), stats.Overflows)
+}
+
+func TestChannel_OverflowDropOldest(t *testing.T) {
+	ch := NewChannelWithOptions("test", newStalledConn(), WriteQueueOptions{Size: 2, Policy: OverflowDropOldest})
+	defer ch.Close()
+	fillQueue(t, ch, 2)
+
+	assert.Nil(t, ch.Push([]byte{9}))
+	stats := ch.QueueStats()
+	assert.Equal(t, 2, stats.Depth)
+	assert.Equal(t, uint64(1), stats.Dropped)
+
+	impl := ch.(*ChannelImpl)
+	assert.Equal(t, []byte{2}, <-impl.writechan)
+	assert.Equal(t, []byte{9}, <-impl.writechan)
+}
+
+func TestChannel_OverflowBlock(t *testing.T) {
+	ch := NewChannelWithOptions("test", newStalledConn(), WriteQueueOptions{Size: 1, Policy: OverflowBlock, Timeout: time.Millisecond * 20})
+	defer ch.Close()
+	fillQueue(t, ch, 1)
+
+	t0 := time.Now()
+	assert.Equal(t, ErrWriteTimeout, ch.Push([]byte{9}))
+	assert.GreaterOrEqual(t, time.Since(t0), time.Millisecond*20)
+	assert.Equal(t, uint64(1), ch.QueueStats().Dropped)
+}
+
+func TestChannel_OverflowDisconnect(t *testing.T) {
+	conn := newStalledConn()
+	ch := NewChannelWithOptions("test", conn, WriteQueueOptions{Size: 1, Policy: OverflowDisconnect})
+	fillQueue(t, ch, 1)
+
+	assert.Equal(t, ErrSlowConsumer, ch.Push([]byte{9}))
+	_, err := ch.ReadFrame()
+	assert.NotNil(t, err)
+	assert.NotNil(t, ch.Push([]byte{10}))
+}
+
+func TestChannel_CloseFlush(t *testing.T) {
+	conn := newStalledConn()
+	ch := NewChannelWithOptions("test", conn, WriteQueueOptions{Size: 4})
+	fillQueue(t, ch, 3)
+
+	go func() {
+		time.Sleep(time.Millisecond * 20)
+		close(conn.release)
+	}()
+	// 队列中的消息在连接关闭之前发送出去
+	assert.Nil(t, ch.Close())
+	assert.Equal(t, int32(4), atomic.LoadInt32(&conn.written))
+	assert.NotNil(t, ch.Push([]byte{9}))
+}
+
+// pooledFrame 记录Release的调用
+type pooledFrame struct {
+	Frame
+	released *int32
+}
+
+func (f *pooledFrame) Release() { atomic.AddInt32(f.released, 1) }
+
+// frameConn 依次返回frames，之后返回错误
+type frameConn struct {
+	*stalledConn
+	frames []Frame
+}
+
+func (c *frameConn) ReadFrame() (Frame, error) {
+	if len(c.frames) == 0 {
+		return nil, net.ErrClosed
+	}
+	f := c.frames[0]
+	c.frames = c.frames[1:]
+	return f, nil
+}
+
+type releaseCheckListener struct {
+	released *int32
+	seen     []int32
+}
+
+func (l *releaseCheckListener) Receive(_ Agent, _ []byte) {
+	l.seen = append(l.seen, atomic.LoadInt32(l.released))
+}
+
+func TestChannel_ReadloopReleaseFrame(t *testing.T) {
+	var released int32
+	newFrame := func(code OpCode, payload []byte) Frame {
+		return &pooledFrame{Frame: &testFrame{op: code, payload: payload}, released: &released}
+	}
+	conn := &frameConn{stalledConn: newStalledConn(), frames: []Frame{
+		newFrame(OpBinary, nil),
+		newFrame(OpBinary, []byte{1}),
+		newFrame(OpBinary, []byte{2}),
+	}}
+	ch := NewChannelWithOptions("test", conn, WriteQueueOptions{Size: 2, Policy: OverflowDropNewest})
+	ch.SetReadDispatcher(InlineDispatcher{})
+	lst := &releaseCheckListener{released: &released}
+	assert.NotNil(t, ch.Readloop(lst))
+
+	// 每个frame都被归还，并且在Receive返回之后才归还
+	assert.Equal(t, int32(3), atomic.LoadInt32(&released))
+	assert.Equal(t, []int32{1, 2}, lst.seen)
+}
@@ -1,26 +1,80 @@
 package HopeIM
 
 import (
-	"github.com/sjmshsh/HopeIM/logger"
 	"sync"
+	"sync/atomic"
+
+	"github.com/sjmshsh/HopeIM/logger"
 )
 
+// ChannelMap 管理服务端所有的Channel
 type ChannelMap interface {
 	Add(channel Channel)
 	Remove(id string)
 	Get(id string) (channel Channel, ok bool)
+	// All 返回所有Channel的拷贝，连接数很多时应该使用Range
 	All() []Channel
+	// Count 返回Channel的数量
+	Count() int
+	// Range 遍历所有的Channel，f返回false时停止遍历。
+	// f中不能同步调用当前ChannelMap的Add、Remove与Index
+	Range(f func(Channel) bool)
+	// Index 为Channel建立一个属性索引，如account，Channel被Remove时自动清除
+	Index(id, key, value string)
+	// Lookup 通过属性查找Channel
+	Lookup(key, value string) []Channel
+}
+
+type channelEntry struct {
+	channel Channel
+	// 已经建立的属性索引
+	attrs []attribute
+}
+
+type attribute struct {
+	key   string
+	value string
+}
+
+type channelShard struct {
+	sync.RWMutex
+	channels map[string]*channelEntry
 }
 
+type indexShard struct {
+	sync.RWMutex
+	index map[attribute]map[string]Channel
+}
+
+// ChannelsImpl 分片的ChannelMap实现
 type ChannelsImpl struct {
-	channels *sync.Map
+	count   int64
+	shards  []*channelShard
+	indexes []*indexShard
 }
 
-// NewChannels NewChannels
+// NewChannels 创建一个ChannelMap，num为分片数
 func NewChannels(num int) ChannelMap {
-	return &ChannelsImpl{
-		channels: new(sync.Map),
+	if num < 1 {
+		num = 1
+	}
+	ch := &ChannelsImpl{
+		shards:  make([]*channelShard, num),
+		indexes: make([]*indexShard, num),
 	}
+	for i := 0; i < num; i++ {
+		ch.shards[i] = &channelShard{channels: make(map[string]*channelEntry)}
+		ch.indexes[i] = &indexShard{index: make(map[attribute]map[string]Channel)}
+	}
+	return ch
+}
+
+func (ch *ChannelsImpl) shard(id string) *channelShard {
+	return ch.shards[hash32(id)%uint32(len(ch.shards))]
+}
+
+func (ch *ChannelsImpl) indexOf(attr attribute) *indexShard {
+	return ch.indexes[hash32(attr.value)%uint32(len(ch.indexes))]
 }
 
 // Add addChannel
@@ -30,13 +84,29 @@ func (ch *ChannelsImpl) Add(channel Channel) {
 			"module": "ChannelsImpl",
 		}).Error("channel id is required")
 	}
-
-	ch.channels.Store(channel.ID(), channel)
+	s := ch.shard(channel.ID())
+	s.Lock()
+	defer s.Unlock()
+	if old, ok := s.channels[channel.ID()]; ok {
+		ch.unindex(channel.ID(), old.attrs)
+	} else {
+		atomic.AddInt64(&ch.count, 1)
+	}
+	s.channels[channel.ID()] = &channelEntry{channel: channel}
 }
 
 // Remove addChannel
 func (ch *ChannelsImpl) Remove(id string) {
-	ch.channels.Delete(id)
+	s := ch.shard(id)
+	s.Lock()
+	defer s.Unlock()
+	entry, ok := s.channels[id]
+	if !ok {
+		return
+	}
+	delete(s.channels, id)
+	atomic.AddInt64(&ch.count, -1)
+	ch.unindex(id, entry.attrs)
 }
 
 // Get Get
@@ -46,20 +116,102 @@ func (ch *ChannelsImpl) Get(id string) (Channel, bool) {
 			"module": "ChannelsImpl",
 		}).Error("channel id is required")
 	}
-
-	val, ok := ch.channels.Load(id)
+	s := ch.shard(id)
+	s.RLock()
+	defer s.RUnlock()
+	entry, ok := s.channels[id]
 	if !ok {
 		return nil, false
 	}
-	return val.(Channel), true
+	return entry.channel, true
 }
 
 // All return channels
 func (ch *ChannelsImpl) All() []Channel {
-	arr := make([]Channel, 0)
-	ch.channels.Range(func(key, val interface{}) bool {
-		arr = append(arr, val.(Channel))
+	arr := make([]Channel, 0, ch.Count())
+	ch.Range(func(channel Channel) bool {
+		arr = append(arr, channel)
 		return true
 	})
 	return arr
 }
+
+// Count Count
+func (ch *ChannelsImpl) Count() int {
+	return int(atomic.LoadInt64(&ch.count))
+}
+
+// Range 逐个分片遍历，遍历某个分片时持有它的读锁
+func (ch *ChannelsImpl) Range(f func(Channel) bool) {
+	for _, s := range ch.shards {
+		if !s.rangeShard(f) {
+			return
+		}
+	}
+}
+
+func (s *channelShard) rangeShard(f func(Channel) bool) bool {
+	s.RLock()
+	defer s.RUnlock()
+	for _, entry := range s.channels {
+		if !f(entry.channel) {
+			return false
+		}
+	}
+	return true
+}
+
+// Index Index
+func (ch *ChannelsImpl) Index(id, key, value string) {
+	attr := attribute{key: key, value: value}
+	s := ch.shard(id)
+	s.Lock()
+	defer s.Unlock()
+	entry, ok := s.channels[id]
+	if !ok {
+		return
+	}
+	entry.attrs = append(entry.attrs, attr)
+
+	idx := ch.indexOf(attr)
+	idx.Lock()
+	defer idx.Unlock()
+	ids, ok := idx.index[attr]
+	if !ok {
+		ids = make(map[string]Channel)
+		idx.index[attr] = ids
+	}
+	ids[id] = entry.channel
+}
+
+// Lookup Lookup
+func (ch *ChannelsImpl) Lookup(key, value string) []Channel {
+	attr := attribute{key: key, value: value}
+	idx := ch.indexOf(attr)
+	idx.RLock()
+	defer idx.RUnlock()
+	ids := idx.index[attr]
+	if len(ids) == 0 {
+		return nil
+	}
+	arr := make([]Channel, 0, len(ids))
+	for _, channel := range ids {
+		arr = append(arr, channel)
+	}
+	return arr
+}
+
+// unindex 调用方需要持有Channel所在分片的锁
+func (ch *ChannelsImpl) unindex(id string, attrs []attribute) {
+	for _, attr := range attrs {
+		idx := ch.indexOf(attr)
+		idx.Lock()
+		if ids, ok := idx.index[attr]; ok {
+			delete(ids, id)
+			if len(ids) == 0 {
+				delete(idx.index, attr)
+			}
+		}
+		idx.Unlock()
+	}
+}
@@ -0,0 +1,81 @@
+package HopeIM
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChannels_CountAndRange(t *testing.T) {
+	channels := NewChannels(8)
+	for i := 0; i < 100; i++ {
+		ch := NewChannel(fmt.Sprintf("ch%d", i), newStalledConn())
+		defer ch.Close()
+		channels.Add(ch)
+	}
+	// 重复添加不影响计数
+	ch0, _ := channels.Get("ch0")
+	channels.Add(ch0)
+	assert.Equal(t, 100, channels.Count())
+
+	channels.Remove("ch1")
+	channels.Remove("ch1")
+	assert.Equal(t, 99, channels.Count())
+	assert.Len(t, channels.All(), 99)
+
+	seen := 0
+	channels.Range(func(Channel) bool {
+		seen++
+		return true
+	})
+	assert.Equal(t, 99, seen)
+
+	seen = 0
+	channels.Range(func(Channel) bool {
+		seen++
+		return seen < 10
+	})
+	assert.Equal(t, 10, seen)
+}
+
+func TestChannels_Lookup(t *testing.T) {
+	channels := NewChannels(4)
+	for _, id := range []string{"a1", "a2", "b1"} {
+		ch := NewChannel(id, newStalledConn())
+		defer ch.Close()
+		channels.Add(ch)
+		channels.Index(id, "account", id[:1])
+	}
+	assert.Len(t, channels.Lookup("account", "a"), 2)
+	assert.Len(t, channels.Lookup("account", "b"), 1)
+	assert.Nil(t, channels.Lookup("account", "c"))
+
+	// 不存在的Channel不会建立索引
+	channels.Index("c1", "account", "c")
+	assert.Nil(t, channels.Lookup("account", "c"))
+
+	channels.Remove("a1")
+	arr := channels.Lookup("account", "a")
+	assert.Len(t, arr, 1)
+	assert.Equal(t, "a2", arr[0].ID())
+
+	channels.Remove("b1")
+	assert.Nil(t, channels.Lookup("account", "b"))
+}
+
+func BenchmarkChannels_Range(b *testing.B) {
+	channels := NewChannels(DefaultChannelShards)
+	for i := 0; i < 10000; i++ {
+		ch := NewChannel(fmt.Sprintf("ch%d", i), newStalledConn())
+		defer ch.Close()
+		channels.Add(ch)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		channels.Range(func(Channel) bool {
+			return true
+		})
+	}
+}
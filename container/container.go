@@ -15,6 +15,7 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -38,6 +39,14 @@ const (
 	KeyServiceState = "service_state"
 )
 
+const DefaultShutdownTimeout = time.Second * 10
+
+// 依赖服务的连接断开之后自动重连，放弃之后从集合中移除，服务再次注册时由Watch重新建立
+const (
+	DefaultReconnectAttempts   = 10
+	DefaultReconnectBufferSize = 1000
+)
+
 type Container struct {
 	sync.RWMutex
 	Naming     naming.Naming
@@ -48,14 +57,17 @@ type Container struct {
 	dialer     HopeIM.Dialer
 	deps       map[string]struct{}
 	monitor    sync.Once
+	// 关闭服务的超时时间，包括排空连接的时间
+	shutdownTimeout time.Duration
 }
 
 var log = logger.WithField("module", "container")
 
 var c = &Container{
-	state:    0,
-	selector: &HashSelector{},
-	deps:     make(map[string]struct{}),
+	state:           0,
+	selector:        &HashSelector{},
+	deps:            make(map[string]struct{}),
+	shutdownTimeout: DefaultShutdownTimeout,
 }
 
 func Init(srv HopeIM.Server, deps ...string) error {
@@ -91,6 +103,13 @@ func EnableMonitor(listen string) {
 	})
 }
 
+// SetShutdownTimeout 设置关闭服务的超时时间，超时之后剩余的连接会被直接关闭
+func SetShutdownTimeout(timeout time.Duration) {
+	if timeout > 0 {
+		c.shutdownTimeout = timeout
+	}
+}
+
 func SetSelector(selector Selector) {
 	c.selector = selector
 }
@@ -171,7 +190,10 @@ func ForwardWithSelector(serviceName string, packet *pkt.LogicPkt, selector Sele
 	// add a tag in packet
 	packet.AddStringMeta(wire.MetaDestServer, c.Srv.ServiceID())
 	log.Debugf("forward message to %v with %s", cli.ServiceID(), &packet.Header)
-	return cli.Send(pkt.Marshal(packet))
+	// Send是同步写入的，之后buffer可以被复用
+	buf := pkt.MarshalBuffer(packet)
+	defer buf.Release()
+	return cli.Send(buf.Bytes())
 }
 
 func lookup(serviceName string, header *pkt.Header, selector Selector) (HopeIM.Client, error) {
@@ -196,17 +218,17 @@ func shutdown() error {
 		return errors.New("has closed")
 	}
 
-	ctx, cancel := context.WithTimeout(context.TODO(), time.Second*10)
+	ctx, cancel := context.WithTimeout(context.TODO(), c.shutdownTimeout)
 	defer cancel()
-	// 1. 优雅关闭服务器
-	err := c.Srv.Shutdown(ctx)
+	// 1. 从注册中心注销服务，不再有新的连接被调度到这里
+	err := c.Naming.Deregister(c.Srv.ServiceID())
 	if err != nil {
-		log.Error(err)
+		log.Warn(err)
 	}
-	// 2. 从注册中心注销服务
-	err = c.Naming.Deregister(c.Srv.ServiceID())
+	// 2. 优雅关闭服务器，排空已有的连接
+	err = c.Srv.Shutdown(ctx)
 	if err != nil {
-		log.Warn(err)
+		log.Error(err)
 	}
 	// 3. 退订服务变更
 	for dep := range c.deps {
@@ -278,10 +300,17 @@ func buildClient(clients ClientMap, service HopeIM.ServiceRegistration) (HopeIM.
 	}
 
 	// 3. 构建客户端并建立连接
+	reconnect := HopeIM.DefaultReconnectOptions()
+	reconnect.MaxAttempts = DefaultReconnectAttempts
+	reconnect.BufferSize = DefaultReconnectBufferSize
+	reconnect.OnStateChange = func(state HopeIM.ConnState, err error) {
+		log.WithField("func", "buildClient").Infof("client %s of %s is %s - %v", id, name, state, err)
+	}
 	cli := tcp.NewClientWithProps(id, name, meta, tcp.ClientOptions{
 		Heartbeat: HopeIM.DefaultHeartbeat,
 		ReadWait:  HopeIM.DefaultReadWait,
 		WriteWait: HopeIM.DefaultWriteWait,
+		Reconnect: &reconnect,
 	})
 	if c.dialer == nil {
 		return nil, fmt.Errorf("dialer is nil")
@@ -291,7 +320,7 @@ func buildClient(clients ClientMap, service HopeIM.ServiceRegistration) (HopeIM.
 	if err != nil {
 		return nil, err
 	}
-	// 4. 读取消息
+	// 4. 读取消息，重连期间readLoop会阻塞在Read，返回错误说明客户端已经关闭或者放弃了重连
 	go func(cli HopeIM.Client) {
 		err := readLoop(cli)
 		if err != nil {
@@ -317,11 +346,13 @@ func readLoop(cli HopeIM.Client) error {
 			return err
 		}
 		if frame.GetOpCode() != HopeIM.OpBinary {
+			HopeIM.ReleaseFrame(frame)
 			continue
 		}
 		buf := bytes.NewBuffer(frame.GetPayload())
 
 		packet, err := pkt.MustReadLogicPkt(buf)
+		HopeIM.ReleaseFrame(frame)
 		if err != nil {
 			log.Info(err)
 			continue
@@ -344,6 +375,15 @@ func pushMessage(packet *pkt.LogicPkt) error {
 		return fmt.Errorf("dest_channels is nil")
 	}
 
+	// 过期的推送直接丢弃，比如在逻辑服务或者网关中排队太久的信令
+	if expiresAt, ok := packet.GetMeta(wire.MetaExpiresAt); ok {
+		if ns, _ := strconv.ParseInt(expiresAt.(string), 10, 64); ns > 0 && time.Now().UnixNano() > ns {
+			log.Debugf("drop expired %s", &packet.Header)
+			return nil
+		}
+		packet.DelMeta(wire.MetaExpiresAt)
+	}
+
 	channelIds := strings.Split(channels.(string), ",")
 	packet.DelMeta(wire.MetaDestServer)
 	packet.DelMeta(wire.MetaDestChannels)
@@ -1,13 +1,19 @@
 package HopeIM
 
 import (
+	"context"
 	"github.com/golang/protobuf/proto"
 	"github.com/sjmshsh/HopeIM/logger"
 	"github.com/sjmshsh/HopeIM/wire"
 	"github.com/sjmshsh/HopeIM/wire/pkt"
+	"math"
 	"sync"
+	"time"
 )
 
+// abortIndex 调用Abort之后index被设置为这个值，后续的handler将不会再执行
+const abortIndex int = math.MaxInt8 / 2
+
 type Session interface {
 	GetChannelId() string
 	GetGateId() string
@@ -21,6 +27,8 @@ type Session interface {
 }
 
 type Context interface {
+	// context.Context 携带了请求的超时及取消信号，可以直接传递给下游的调用
+	context.Context
 	Dispather
 	SessionStorage
 	Header() *pkt.Header
@@ -29,6 +37,15 @@ type Context interface {
 	RespWithError(status pkt.Status, err error) error
 	Resp(status pkt.Status, body proto.Message) error
 	Dispatch(body proto.Message, recvs ...*Location) error
+	// DispatchWithMeta 与Dispatch相同，推送的消息中带上metas，比如网关丢弃过期推送使用的MetaExpiresAt
+	DispatchWithMeta(metas []*pkt.Meta, body proto.Message, recvs ...*Location) error
+	// Notify 推送一条服务端发起的通知，指令为command，不沿用请求的header
+	Notify(command string, body proto.Message, recvs ...*Location) error
+	// Next 执行调用链中剩余的handler，只能在middleware中调用
+	Next()
+	// Abort 阻止调用链中剩余的handler被执行，不会中断当前handler
+	Abort()
+	IsAborted() bool
 }
 
 type ContextImpl struct {
@@ -44,6 +61,14 @@ type ContextImpl struct {
 	request *pkt.LogicPkt
 	// 发送方会话
 	session Session
+	// Dispatch时单次推送给网关的最大channel数量
+	dispatchBatch int
+	// 请求的超时及取消信号
+	ctx context.Context
+	// responded 是否已经返回过Resp
+	responded bool
+	// timedout 请求已经超时，之后的Resp将被忽略
+	timedout bool
 }
 
 type HandlerFunc func(Context)
@@ -51,17 +76,26 @@ type HandlerFunc func(Context)
 type HandlersChain []HandlerFunc
 
 func BuildContext() Context {
-	return &ContextImpl{}
+	return &ContextImpl{ctx: context.Background()}
 }
 
+// Next 依次执行调用链中剩余的handler
 func (c *ContextImpl) Next() {
-	if c.index >= len(c.handlers) {
-		return
+	c.index++
+	for c.index < len(c.handlers) {
+		c.handlers[c.index](c)
+		c.index++
 	}
-	f := c.handlers[c.index]
+}
 
-	f(c)
-	c.index++
+// Abort 阻止调用链中剩余的handler被执行
+func (c *ContextImpl) Abort() {
+	c.index = abortIndex
+}
+
+// IsAborted 返回当前调用链是否已经被中止
+func (c *ContextImpl) IsAborted() bool {
+	return c.index >= abortIndex
 }
 
 // RespWithError response with error
@@ -71,24 +105,69 @@ func (c *ContextImpl) RespWithError(status pkt.Status, err error) error {
 
 // Resp send a response message to sender, the header of packet copied from request
 func (c *ContextImpl) Resp(status pkt.Status, body proto.Message) error {
+	c.Lock()
+	// 请求的ctx已经结束但是timeout还没有被调用时，同样由timeout返回错误
+	if c.timedout || c.ctx.Err() != nil {
+		c.Unlock()
+		return c.ctx.Err()
+	}
+	c.responded = true
+	c.Unlock()
+	return c.resp(c.ctx, status, body)
+}
+
+func (c *ContextImpl) resp(ctx context.Context, status pkt.Status, body proto.Message) error {
 	packet := pkt.NewFrom(&c.request.Header)
 	packet.Status = status
 	packet.WriteBody(body)
 	packet.Flag = pkt.Flag_Response
 	logger.Debugf("<-- Resp to %s command:%s  status: %v body: %s", c.Session().GetAccount(), &c.request.Header, status, body)
 
-	err := c.Push(c.Session().GetGateId(), []string{c.Session().GetChannelId()}, packet)
+	err := c.Push(ctx, c.Session().GetGateId(), []string{c.Session().GetChannelId()}, packet)
 	if err != nil {
 		logger.Error(err)
 	}
 	return err
 }
 
+// timeout 在请求超时之后调用，如果handler还没有返回Resp，就返回一个SystemException
+func (c *ContextImpl) timeout() {
+	c.Lock()
+	c.timedout = true
+	responded := c.responded
+	c.Unlock()
+	if responded {
+		return
+	}
+	logger.Warnf("command %s of %s timeout", c.request.Command, c.session.GetAccount())
+	// 请求的ctx已经超时，返回错误时使用一个新的ctx
+	_ = c.resp(context.Background(), pkt.Status_SystemException, &pkt.ErrorResp{Message: c.ctx.Err().Error()})
+}
+
+func (c *ContextImpl) Deadline() (time.Time, bool) {
+	return c.ctx.Deadline()
+}
+
+func (c *ContextImpl) Done() <-chan struct{} {
+	return c.ctx.Done()
+}
+
+func (c *ContextImpl) Err() error {
+	return c.ctx.Err()
+}
+
+func (c *ContextImpl) Value(key interface{}) interface{} {
+	return c.ctx.Value(key)
+}
+
 func (c *ContextImpl) reset() {
 	c.request = nil
-	c.index = 0
-	c.handlers = nil
+	c.index = -1
+	c.handlers = c.handlers[:0]
 	c.session = nil
+	c.ctx = context.Background()
+	c.responded = false
+	c.timedout = false
 }
 
 func (c *ContextImpl) Header() *pkt.Header {
@@ -112,32 +191,55 @@ func (c *ContextImpl) Session() Session {
 }
 
 func (c *ContextImpl) Dispatch(body proto.Message, recvs ...*Location) error {
+	return c.dispatch(&c.request.Header, nil, body, recvs)
+}
+
+func (c *ContextImpl) DispatchWithMeta(metas []*pkt.Meta, body proto.Message, recvs ...*Location) error {
+	return c.dispatch(&c.request.Header, metas, body, recvs)
+}
+
+// Notify 比如在线状态的变化，消息的指令与触发变化的请求无关
+func (c *ContextImpl) Notify(command string, body proto.Message, recvs ...*Location) error {
+	return c.dispatch(&pkt.New(command).Header, nil, body, recvs)
+}
+
+func (c *ContextImpl) dispatch(header *pkt.Header, metas []*pkt.Meta, body proto.Message, recvs []*Location) error {
 	if len(recvs) == 0 {
 		return nil
 	}
-	packet := pkt.NewFrom(&c.request.Header)
-	packet.Flag = pkt.Flag_Push
-	packet.WriteBody(body)
-
-	logger.Debugf("<-- Dispatch to %d users command:%s", len(recvs), &c.request.Header)
+	logger.Debugf("<-- Dispatch to %d users command:%s", len(recvs), header)
 
-	// the receivers group by the destination of gateway
-	group := make(map[string][]string)
+	// the receivers group by the content type and the destination of gateway,
+	// the body is encoded once for each content type, and pushed to all gateways concurrently
+	groups := make(map[pkt.ContentType]map[string][]string)
 	for _, recv := range recvs {
 		if recv.ChannelId == c.Session().GetChannelId() {
 			continue
 		}
-		if _, ok := group[recv.GateId]; !ok {
-			group[recv.GateId] = make([]string, 0)
+		group, ok := groups[recv.ContentType]
+		if !ok {
+			group = make(map[string][]string)
+			groups[recv.ContentType] = group
 		}
 		group[recv.GateId] = append(group[recv.GateId], recv.ChannelId)
 	}
-	for gateway, ids := range group {
-		err := c.Push(gateway, ids, packet)
-		if err != nil {
-			logger.Error(err)
+	result := &DispatchError{}
+	for contentType, group := range groups {
+		packet := pkt.NewFrom(header)
+		packet.Flag = pkt.Flag_Push
+		packet.ContentType = contentType
+		packet.AddMeta(metas...)
+		packet.WriteBody(body)
+		for _, channels := range group {
+			result.Total += len(channels)
 		}
-		return err
+		if err, ok := fanout(c.ctx, c.Dispather, packet, group, c.dispatchBatch, DefaultDispatchConcurrency).(*DispatchError); ok {
+			result.Failed = append(result.Failed, err.Failed...)
+		}
+	}
+	if len(result.Failed) > 0 {
+		logger.Error(result)
+		return result
 	}
 	return nil
 }
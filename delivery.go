@@ -0,0 +1,365 @@
+package HopeIM
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/sjmshsh/HopeIM/logger"
+	"github.com/sjmshsh/HopeIM/wire/pkt"
+)
+
+const (
+	DefaultDeliveryInterval    = time.Second * 2
+	DefaultDeliveryMaxInterval = time.Second * 16
+	DefaultDeliveryMaxRetries  = 5
+	DefaultDeliveryMaxPending  = 1000
+)
+
+// 推送的结果
+const (
+	DeliveryAcked   = "acked"
+	DeliveryExpired = "expired"
+	DeliveryDropped = "dropped"
+)
+
+var (
+	deliveryResults = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "hopeim",
+		Name:      "delivery_total",
+		Help:      "number of tracked pushes by result, expired and dropped ones are left to offline sync",
+	}, []string{"result"})
+	deliveryRetries = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "hopeim",
+		Name:      "delivery_retries_total",
+		Help:      "number of retransmitted pushes",
+	})
+	deliveryLatency = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: "hopeim",
+		Name:      "delivery_latency_seconds",
+		Help:      "time from the first push to the ack of the receiver",
+		Buckets:   []float64{.01, .05, .1, .25, .5, 1, 2.5, 5, 10, 30},
+	})
+)
+
+// DeliveryOptions 可靠推送的配置
+type DeliveryOptions struct {
+	// Interval 第一次重传之前等待ack的时间，之后每次翻倍，最多为MaxInterval
+	Interval    time.Duration
+	MaxInterval time.Duration
+	// MaxRetries 最多重传的次数，之后交给离线同步
+	MaxRetries int
+	// MaxPending 每个channel等待ack的最大消息数，超过之后新的消息不再跟踪
+	MaxPending int
+}
+
+// DefaultDeliveryOptions DefaultDeliveryOptions
+func DefaultDeliveryOptions() DeliveryOptions {
+	return DeliveryOptions{
+		Interval:    DefaultDeliveryInterval,
+		MaxInterval: DefaultDeliveryMaxInterval,
+		MaxRetries:  DefaultDeliveryMaxRetries,
+		MaxPending:  DefaultDeliveryMaxPending,
+	}
+}
+
+// DeliveryStore 多个逻辑服务共享的待确认推送。接收方的ack按照接收方的channel路由，
+// 可能到达任意一个节点，由它从DeliveryStore中删除；负责重传的节点在每次重传之前检查推送是否还存在
+type DeliveryStore interface {
+	// Add 记录一条等待ack的推送
+	Add(channelId string, messageId int64, sent time.Time) error
+	// Remove 删除一条推送并返回第一次推送的时间，ok为false表示它已经被确认或者放弃
+	Remove(channelId string, messageId int64) (sent time.Time, ok bool, err error)
+	// Exists 推送是否还在等待ack
+	Exists(channelId string, messageId int64) (bool, error)
+	// Clear 删除channel所有等待ack的推送
+	Clear(channelId string) error
+}
+
+// Delivery 跟踪每个接收方channel的推送，在收到对应消息的ack之前按照退避间隔重传，
+// 超过重传次数之后放弃，由客户端的离线同步补偿
+type Delivery struct {
+	sync.Mutex
+	dispather Dispather
+	store     DeliveryStore
+	opts      DeliveryOptions
+	// channelId -> messageId -> pendingPush
+	pending map[string]map[int64]*pendingPush
+	closed  bool
+}
+
+type pendingPush struct {
+	gateway   string
+	channel   string
+	messageId int64
+	packet    *pkt.LogicPkt
+	sent      time.Time
+	tries     int
+	timer     *time.Timer
+}
+
+// NewDelivery store为nil时使用MemoryDeliveryStore，只适用于单个节点
+func NewDelivery(dispather Dispather, store DeliveryStore, opts DeliveryOptions) *Delivery {
+	if store == nil {
+		store = NewMemoryDeliveryStore()
+	}
+	def := DefaultDeliveryOptions()
+	if opts.Interval <= 0 {
+		opts.Interval = def.Interval
+	}
+	if opts.MaxInterval < opts.Interval {
+		opts.MaxInterval = opts.Interval
+	}
+	if opts.MaxRetries <= 0 {
+		opts.MaxRetries = def.MaxRetries
+	}
+	if opts.MaxPending <= 0 {
+		opts.MaxPending = def.MaxPending
+	}
+	return &Delivery{
+		dispather: dispather,
+		store:     store,
+		opts:      opts,
+		pending:   make(map[string]map[int64]*pendingPush),
+	}
+}
+
+// Dispatch 通过ctx推送消息，并跟踪除发送方之外的每一个接收方。
+// 第一次推送失败的channel同样会被重传，返回值与ctx.Dispatch相同
+func (d *Delivery) Dispatch(ctx Context, messageId int64, body proto.Message, recvs ...*Location) error {
+	err := ctx.Dispatch(body, recvs...)
+
+	packets := make(map[pkt.ContentType]*pkt.LogicPkt)
+	for _, recv := range recvs {
+		if recv.ChannelId == ctx.Session().GetChannelId() {
+			continue
+		}
+		packet, ok := packets[recv.ContentType]
+		if !ok {
+			packet = pkt.NewFrom(ctx.Header())
+			packet.Flag = pkt.Flag_Push
+			packet.ContentType = recv.ContentType
+			packet.WriteBody(body)
+			packets[recv.ContentType] = packet
+		}
+		d.track(recv, messageId, packet)
+	}
+	return err
+}
+
+func (d *Delivery) track(recv *Location, messageId int64, packet *pkt.LogicPkt) {
+	d.Lock()
+	defer d.Unlock()
+	if d.closed {
+		return
+	}
+	pushes, ok := d.pending[recv.ChannelId]
+	if !ok {
+		pushes = make(map[int64]*pendingPush)
+		d.pending[recv.ChannelId] = pushes
+	}
+	if _, ok := pushes[messageId]; ok {
+		return
+	}
+	if len(pushes) >= d.opts.MaxPending {
+		deliveryResults.WithLabelValues(DeliveryDropped).Inc()
+		return
+	}
+	p := &pendingPush{
+		gateway:   recv.GateId,
+		channel:   recv.ChannelId,
+		messageId: messageId,
+		packet:    packet,
+		sent:      time.Now(),
+	}
+	if err := d.store.Add(recv.ChannelId, messageId, p.sent); err != nil {
+		logger.WithField("module", "delivery").Warnf("track message %d to %s failed: %v", messageId, recv.ChannelId, err)
+		return
+	}
+	p.timer = time.AfterFunc(d.opts.Interval, func() {
+		d.retransmit(p)
+	})
+	pushes[messageId] = p
+}
+
+// Ack 接收方确认收到了消息，推送可能是由其它节点跟踪的。返回false表示消息没有被跟踪或者已经放弃
+func (d *Delivery) Ack(channelId string, messageId int64) bool {
+	d.Lock()
+	p, ok := d.remove(channelId, messageId)
+	d.Unlock()
+	if ok {
+		p.timer.Stop()
+	}
+	sent, ok, err := d.store.Remove(channelId, messageId)
+	if err != nil {
+		logger.WithField("module", "delivery").Warnf("ack message %d of %s failed: %v", messageId, channelId, err)
+		return false
+	}
+	if !ok {
+		return false
+	}
+	deliveryResults.WithLabelValues(DeliveryAcked).Inc()
+	deliveryLatency.Observe(time.Since(sent).Seconds())
+	return true
+}
+
+// Pending 返回channel等待ack的消息数
+func (d *Delivery) Pending(channelId string) int {
+	d.Lock()
+	defer d.Unlock()
+	return len(d.pending[channelId])
+}
+
+// Forget channel已经退出或者被踢下线，停止并删除它所有等待ack的推送。
+// 其它节点跟踪的推送在下一次重传之前发现已经不存在，同样不再重传
+func (d *Delivery) Forget(channelId string) {
+	d.Lock()
+	pushes := d.pending[channelId]
+	delete(d.pending, channelId)
+	d.Unlock()
+	for _, p := range pushes {
+		p.timer.Stop()
+		deliveryResults.WithLabelValues(DeliveryDropped).Inc()
+	}
+	if err := d.store.Clear(channelId); err != nil {
+		logger.WithField("module", "delivery").Warnf("clear pending messages of %s failed: %v", channelId, err)
+	}
+}
+
+// Close 停止所有的重传，未确认的消息交给离线同步
+func (d *Delivery) Close() {
+	d.Lock()
+	defer d.Unlock()
+	d.closed = true
+	for _, pushes := range d.pending {
+		for _, p := range pushes {
+			p.timer.Stop()
+		}
+	}
+	d.pending = make(map[string]map[int64]*pendingPush)
+}
+
+func (d *Delivery) retransmit(p *pendingPush) {
+	log := logger.WithField("module", "delivery")
+	// ack可能已经被其它节点处理
+	exists, err := d.store.Exists(p.channel, p.messageId)
+	if err != nil {
+		log.Warnf("check message %d to %s failed: %v", p.messageId, p.channel, err)
+		exists = true
+	}
+	d.Lock()
+	if _, ok := d.pending[p.channel][p.messageId]; !ok {
+		d.Unlock()
+		return
+	}
+	if !exists {
+		d.remove(p.channel, p.messageId)
+		d.Unlock()
+		return
+	}
+	if p.tries >= d.opts.MaxRetries {
+		d.remove(p.channel, p.messageId)
+		d.Unlock()
+		if _, ok, _ := d.store.Remove(p.channel, p.messageId); ok {
+			deliveryResults.WithLabelValues(DeliveryExpired).Inc()
+			log.Debugf("message %d to %s expired after %d retries", p.messageId, p.channel, p.tries)
+		}
+		return
+	}
+	p.tries++
+	interval := d.opts.Interval << uint(p.tries)
+	if interval > d.opts.MaxInterval || interval <= 0 {
+		interval = d.opts.MaxInterval
+	}
+	p.timer.Reset(interval)
+	d.Unlock()
+
+	deliveryRetries.Inc()
+	// Dispather会在packet中添加meta，每次重传都使用一个新的packet
+	packet := pkt.NewFrom(&p.packet.Header)
+	packet.Flag = p.packet.Flag
+	packet.Body = p.packet.Body
+	ctx, cancel := context.WithTimeout(context.Background(), d.opts.Interval)
+	defer cancel()
+	if err := d.dispather.Push(ctx, p.gateway, []string{p.channel}, packet); err != nil {
+		log.Debugf("retransmit message %d to %s failed: %v", p.messageId, p.channel, err)
+	}
+}
+
+func (d *Delivery) remove(channelId string, messageId int64) (*pendingPush, bool) {
+	pushes, ok := d.pending[channelId]
+	if !ok {
+		return nil, false
+	}
+	p, ok := pushes[messageId]
+	if !ok {
+		return nil, false
+	}
+	delete(pushes, messageId)
+	if len(pushes) == 0 {
+		delete(d.pending, channelId)
+	}
+	return p, true
+}
+
+// MemoryDeliveryStore 单节点的DeliveryStore
+type MemoryDeliveryStore struct {
+	sync.Mutex
+	// channelId -> messageId -> 第一次推送的时间
+	pushes map[string]map[int64]time.Time
+}
+
+// NewMemoryDeliveryStore NewMemoryDeliveryStore
+func NewMemoryDeliveryStore() *MemoryDeliveryStore {
+	return &MemoryDeliveryStore{
+		pushes: make(map[string]map[int64]time.Time),
+	}
+}
+
+// Add Add
+func (m *MemoryDeliveryStore) Add(channelId string, messageId int64, sent time.Time) error {
+	m.Lock()
+	defer m.Unlock()
+	pushes, ok := m.pushes[channelId]
+	if !ok {
+		pushes = make(map[int64]time.Time)
+		m.pushes[channelId] = pushes
+	}
+	pushes[messageId] = sent
+	return nil
+}
+
+// Remove Remove
+func (m *MemoryDeliveryStore) Remove(channelId string, messageId int64) (time.Time, bool, error) {
+	m.Lock()
+	defer m.Unlock()
+	pushes := m.pushes[channelId]
+	sent, ok := pushes[messageId]
+	if !ok {
+		return time.Time{}, false, nil
+	}
+	delete(pushes, messageId)
+	if len(pushes) == 0 {
+		delete(m.pushes, channelId)
+	}
+	return sent, true, nil
+}
+
+// Clear Clear
+func (m *MemoryDeliveryStore) Clear(channelId string) error {
+	m.Lock()
+	defer m.Unlock()
+	delete(m.pushes, channelId)
+	return nil
+}
+
+// Exists Exists
+func (m *MemoryDeliveryStore) Exists(channelId string, messageId int64) (bool, error) {
+	m.Lock()
+	defer m.Unlock()
+	_, ok := m.pushes[channelId][messageId]
+	return ok, nil
+}
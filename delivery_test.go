@@ -0,0 +1,115 @@
+package HopeIM
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sjmshsh/HopeIM/wire/pkt"
+	"github.com/stretchr/testify/assert"
+)
+
+func (d *testDispather) count() int {
+	d.Lock()
+	defer d.Unlock()
+	return len(d.packets)
+}
+
+func TestDelivery(t *testing.T) {
+	d := &testDispather{}
+	delivery := NewDelivery(d, nil, DeliveryOptions{
+		Interval:    time.Millisecond * 20,
+		MaxInterval: time.Millisecond * 40,
+		MaxRetries:  2,
+	})
+	defer delivery.Close()
+
+	r := NewRouter()
+	r.Handle("chat.user.talk", func(ctx Context) {
+		_ = delivery.Dispatch(ctx, 1, &pkt.MessagePush{MessageId: 1, Body: "hello"},
+			&Location{ChannelId: "ch1", GateId: "gate1"},
+			&Location{ChannelId: "ch2", GateId: "gate1"},
+			&Location{ChannelId: "ch3", GateId: "gate2"},
+		)
+	})
+	_ = r.Serve(pkt.New("chat.user.talk"), d, &testStorage{}, testSession)
+	assert.Equal(t, 2, d.count())
+	// 发送方不需要跟踪
+	assert.Equal(t, 0, delivery.Pending("ch1"))
+	assert.Equal(t, 1, delivery.Pending("ch2"))
+
+	// ch2收到之后确认
+	assert.True(t, delivery.Ack("ch2", 1))
+	assert.False(t, delivery.Ack("ch2", 1))
+
+	// ch3没有确认，重传两次之后放弃
+	assert.Eventually(t, func() bool {
+		return delivery.Pending("ch3") == 0
+	}, time.Second, time.Millisecond*10)
+	assert.Equal(t, 4, d.count())
+
+	d.Lock()
+	retry := d.packets[3]
+	d.Unlock()
+	assert.Equal(t, pkt.Flag_Push, retry.Flag)
+	var push pkt.MessagePush
+	assert.Nil(t, retry.ReadBody(&push))
+	assert.Equal(t, "hello", push.Body)
+}
+
+func TestDelivery_AckOnAnotherInstance(t *testing.T) {
+	store := NewMemoryDeliveryStore()
+	d1 := &testDispather{}
+	delivery1 := NewDelivery(d1, store, DeliveryOptions{
+		Interval:   time.Millisecond * 20,
+		MaxRetries: 5,
+	})
+	defer delivery1.Close()
+	delivery2 := NewDelivery(&testDispather{}, store, DeliveryOptions{})
+	defer delivery2.Close()
+
+	r := NewRouter()
+	r.Handle("chat.user.talk", func(ctx Context) {
+		_ = delivery1.Dispatch(ctx, 1, &pkt.MessagePush{MessageId: 1, Body: "hello"},
+			&Location{ChannelId: "ch2", GateId: "gate1"},
+		)
+	})
+	_ = r.Serve(pkt.New("chat.user.talk"), d1, &testStorage{}, testSession)
+	assert.Equal(t, 1, delivery1.Pending("ch2"))
+
+	// ack按照接收方的channel路由到了另一个节点
+	assert.True(t, delivery2.Ack("ch2", 1))
+	assert.False(t, delivery2.Ack("ch2", 1))
+
+	// 负责推送的节点在下一次重传之前发现已经确认，不再重传
+	assert.Eventually(t, func() bool {
+		return delivery1.Pending("ch2") == 0
+	}, time.Second, time.Millisecond*10)
+	assert.Equal(t, 1, d1.count())
+}
+
+func TestDelivery_Forget(t *testing.T) {
+	store := NewMemoryDeliveryStore()
+	d := &testDispather{}
+	delivery := NewDelivery(d, store, DeliveryOptions{
+		Interval:   time.Millisecond * 20,
+		MaxRetries: 5,
+	})
+	defer delivery.Close()
+
+	r := NewRouter()
+	r.Handle("chat.user.talk", func(ctx Context) {
+		_ = delivery.Dispatch(ctx, 1, &pkt.MessagePush{MessageId: 1, Body: "hello"},
+			&Location{ChannelId: "ch2", GateId: "gate1"},
+		)
+	})
+	_ = r.Serve(pkt.New("chat.user.talk"), d, &testStorage{}, testSession)
+	assert.Equal(t, 1, delivery.Pending("ch2"))
+
+	// ch2退出之后不再重传
+	delivery.Forget("ch2")
+	assert.Equal(t, 0, delivery.Pending("ch2"))
+	ok, _ := store.Exists("ch2", 1)
+	assert.False(t, ok)
+	time.Sleep(time.Millisecond * 60)
+	assert.Equal(t, 1, d.count())
+}
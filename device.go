@@ -0,0 +1,103 @@
+package HopeIM
+
+import (
+	"fmt"
+
+	"github.com/sjmshsh/HopeIM/wire"
+)
+
+// DevicePolicy 同一个账号在多个设备上登录时的策略
+type DevicePolicy string
+
+const (
+	// DevicePolicyPerDevice 每种设备类型只保留一个会话，同类型设备的新登录踢掉旧的会话
+	DevicePolicyPerDevice DevicePolicy = "per_device"
+	// DevicePolicyMobileDesktop 移动端(ios/android)与桌面端(web/pc)互斥，在一端登录时踢掉另一端的所有会话，
+	// 同一端的设备按照DevicePolicyPerDevice处理
+	DevicePolicyMobileDesktop DevicePolicy = "mobile_desktop"
+	// DevicePolicyUnlimited 不限制登录的会话
+	DevicePolicyUnlimited DevicePolicy = "unlimited"
+)
+
+const (
+	DeviceCategoryMobile  = "mobile"
+	DeviceCategoryDesktop = "desktop"
+)
+
+// DeviceCategory 设备所属的端，mobile或者desktop，未知的设备类型返回它本身
+func DeviceCategory(device string) string {
+	switch device {
+	case wire.DeviceIOS, wire.DeviceAndroid:
+		return DeviceCategoryMobile
+	case wire.DeviceWeb, wire.DevicePC:
+		return DeviceCategoryDesktop
+	}
+	return device
+}
+
+// exclusive 两个设备是否分别属于移动端与桌面端
+func exclusive(a, b string) bool {
+	ca, cb := DeviceCategory(a), DeviceCategory(b)
+	return (ca == DeviceCategoryMobile && cb == DeviceCategoryDesktop) ||
+		(ca == DeviceCategoryDesktop && cb == DeviceCategoryMobile)
+}
+
+// Conflicts 返回在device上登录时需要踢下线的会话
+func (p DevicePolicy) Conflicts(device string, locs []*Location) []*Location {
+	var conflicts []*Location
+	for _, loc := range locs {
+		switch p {
+		case DevicePolicyUnlimited:
+			continue
+		case DevicePolicyMobileDesktop:
+			if loc.Device != device && !exclusive(loc.Device, device) {
+				continue
+			}
+		default:
+			if loc.Device != device {
+				continue
+			}
+		}
+		conflicts = append(conflicts, loc)
+	}
+	return conflicts
+}
+
+// DevicePolicies 按照app配置的登录策略
+type DevicePolicies struct {
+	// Default 没有单独配置的app使用的策略，为空时使用DevicePolicyPerDevice
+	Default DevicePolicy
+	Apps    map[string]DevicePolicy
+}
+
+// Of 返回app的登录策略
+func (p DevicePolicies) Of(app string) DevicePolicy {
+	if policy, ok := p.Apps[app]; ok {
+		return policy
+	}
+	if p.Default == "" {
+		return DevicePolicyPerDevice
+	}
+	return p.Default
+}
+
+// Validate 检查配置中的策略，空的Default之外未知的策略返回错误
+func (p DevicePolicies) Validate() error {
+	if p.Default != "" && !p.Default.valid() {
+		return fmt.Errorf("unknown device policy %s", p.Default)
+	}
+	for app, policy := range p.Apps {
+		if !policy.valid() {
+			return fmt.Errorf("unknown device policy %s of app %s", policy, app)
+		}
+	}
+	return nil
+}
+
+func (p DevicePolicy) valid() bool {
+	switch p {
+	case DevicePolicyPerDevice, DevicePolicyMobileDesktop, DevicePolicyUnlimited:
+		return true
+	}
+	return false
+}
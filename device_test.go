@@ -0,0 +1,52 @@
+package HopeIM
+
+import (
+	"testing"
+
+	"github.com/sjmshsh/HopeIM/wire"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDevicePolicy_Conflicts(t *testing.T) {
+	locs := []*Location{
+		{ChannelId: "ch1", Device: wire.DeviceIOS},
+		{ChannelId: "ch2", Device: wire.DevicePC},
+		{ChannelId: "ch3", Device: wire.DeviceWeb},
+	}
+	channels := func(locs []*Location) []string {
+		var ids []string
+		for _, loc := range locs {
+			ids = append(ids, loc.ChannelId)
+		}
+		return ids
+	}
+
+	assert.Equal(t, []string{"ch1"}, channels(DevicePolicyPerDevice.Conflicts(wire.DeviceIOS, locs)))
+	assert.Empty(t, DevicePolicyPerDevice.Conflicts(wire.DeviceAndroid, locs))
+
+	// 移动端登录踢掉桌面端，同一端只踢掉相同类型的设备
+	assert.Equal(t, []string{"ch1", "ch2", "ch3"}, channels(DevicePolicyMobileDesktop.Conflicts(wire.DeviceIOS, locs)))
+	assert.Equal(t, []string{"ch2", "ch3"}, channels(DevicePolicyMobileDesktop.Conflicts(wire.DeviceAndroid, locs)))
+	// 桌面端登录踢掉移动端
+	assert.Equal(t, []string{"ch1", "ch2"}, channels(DevicePolicyMobileDesktop.Conflicts(wire.DevicePC, locs)))
+	assert.Equal(t, []string{"ch1"}, channels(DevicePolicyMobileDesktop.Conflicts(wire.DevicePC, locs[:1])))
+	assert.Empty(t, DevicePolicyMobileDesktop.Conflicts(wire.DeviceWeb, locs[1:2]))
+
+	assert.Empty(t, DevicePolicyUnlimited.Conflicts(wire.DeviceIOS, locs))
+}
+
+func TestDevicePolicies_Of(t *testing.T) {
+	policies := DevicePolicies{Apps: map[string]DevicePolicy{"im": DevicePolicyUnlimited}}
+	assert.Equal(t, DevicePolicyUnlimited, policies.Of("im"))
+	assert.Equal(t, DevicePolicyPerDevice, policies.Of("other"))
+
+	policies.Default = DevicePolicyMobileDesktop
+	assert.Equal(t, DevicePolicyMobileDesktop, policies.Of("other"))
+}
+
+func TestDevicePolicies_Validate(t *testing.T) {
+	assert.Nil(t, DevicePolicies{}.Validate())
+	assert.Nil(t, DevicePolicies{Default: DevicePolicyUnlimited, Apps: map[string]DevicePolicy{"im": DevicePolicyMobileDesktop}}.Validate())
+	assert.NotNil(t, DevicePolicies{Default: "per-device"}.Validate())
+	assert.NotNil(t, DevicePolicies{Apps: map[string]DevicePolicy{"im": ""}}.Validate())
+}
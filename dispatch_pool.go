@@ -0,0 +1,119 @@
+package HopeIM
+
+import (
+	"errors"
+	"hash/fnv"
+	"sync"
+)
+
+var ErrDispatcherClosed = errors.New("err:read dispatcher closed")
+
+// ReadDispatcher 定义了Readloop中读取到的消息如何交给MessageListener处理
+type ReadDispatcher interface {
+	Dispatch(ag Agent, payload []byte, lst MessageListener) error
+}
+
+// GoroutineDispatcher 每条消息启动一个goroutine处理，不保证消息的顺序
+type GoroutineDispatcher struct{}
+
+func (d GoroutineDispatcher) Dispatch(ag Agent, payload []byte, lst MessageListener) error {
+	go lst.Receive(ag, payload)
+	return nil
+}
+
+// InlineDispatcher 直接在Readloop中同步处理，消息有序，但是会阻塞读取
+type InlineDispatcher struct{}
+
+func (d InlineDispatcher) Dispatch(ag Agent, payload []byte, lst MessageListener) error {
+	lst.Receive(ag, payload)
+	return nil
+}
+
+type poolTask struct {
+	ag      Agent
+	payload []byte
+	lst     MessageListener
+}
+
+// PoolDispatcher 由固定数量的worker处理消息。
+// 同一个Channel的消息总是交给同一个worker，因此可以保证单个Channel内的消息顺序；
+// worker的队列满了之后，Dispatch会阻塞，由此对Readloop形成背压。
+type PoolDispatcher struct {
+	queues []chan poolTask
+	once   sync.Once
+	wg     sync.WaitGroup
+	closed *Event
+}
+
+// NewPoolDispatcher 创建一个有workers个worker，每个worker队列长度为queueSize的分发器
+func NewPoolDispatcher(workers, queueSize int) *PoolDispatcher {
+	if workers <= 0 {
+		workers = DefaultReadWorkers
+	}
+	if queueSize <= 0 {
+		queueSize = DefaultReadQueueSize
+	}
+	p := &PoolDispatcher{
+		queues: make([]chan poolTask, workers),
+		closed: NewEvent(),
+	}
+	p.wg.Add(workers)
+	for i := range p.queues {
+		p.queues[i] = make(chan poolTask, queueSize)
+		go p.work(p.queues[i])
+	}
+	return p
+}
+
+func (p *PoolDispatcher) work(queue chan poolTask) {
+	defer p.wg.Done()
+	for {
+		select {
+		case task := <-queue:
+			task.lst.Receive(task.ag, task.payload)
+		case <-p.closed.Done():
+			return
+		}
+	}
+}
+
+// Dispatch 投递一条消息到ag对应的worker中
+func (p *PoolDispatcher) Dispatch(ag Agent, payload []byte, lst MessageListener) error {
+	if p.closed.HasFired() {
+		return ErrDispatcherClosed
+	}
+	queue := p.queues[p.index(ag.ID())]
+	select {
+	case queue <- poolTask{ag: ag, payload: payload, lst: lst}:
+		return nil
+	case <-p.closed.Done():
+		return ErrDispatcherClosed
+	}
+}
+
+// Pending 返回所有worker中等待处理的消息数
+func (p *PoolDispatcher) Pending() int {
+	n := 0
+	for _, q := range p.queues {
+		n += len(q)
+	}
+	return n
+}
+
+// Close 停止所有worker，队列中未处理的消息会被丢弃
+func (p *PoolDispatcher) Close() {
+	p.once.Do(func() {
+		p.closed.Fire()
+		p.wg.Wait()
+	})
+}
+
+func (p *PoolDispatcher) index(id string) int {
+	return int(hash32(id) % uint32(len(p.queues)))
+}
+
+func hash32(s string) uint32 {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(s))
+	return h.Sum32()
+}
@@ -0,0 +1,60 @@
+package HopeIM
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testAgent string
+
+func (a testAgent) ID() string              { return string(a) }
+func (a testAgent) Push(data []byte) error  { return nil }
+func (a testAgent) Attributes() *Attributes { return NewAttributes() }
+
+type orderListener struct {
+	sync.Mutex
+	wg   *sync.WaitGroup
+	recv map[string][]byte
+}
+
+func (l *orderListener) Receive(ag Agent, payload []byte) {
+	l.Lock()
+	l.recv[ag.ID()] = append(l.recv[ag.ID()], payload[0])
+	l.Unlock()
+	l.wg.Done()
+}
+
+func TestPoolDispatcher_Ordered(t *testing.T) {
+	p := NewPoolDispatcher(4, 2)
+	defer p.Close()
+
+	const agents, count = 10, 100
+	wg := &sync.WaitGroup{}
+	wg.Add(agents * count)
+	lst := &orderListener{wg: wg, recv: make(map[string][]byte)}
+	for i := 0; i < agents; i++ {
+		go func(ag Agent) {
+			for j := 0; j < count; j++ {
+				assert.Nil(t, p.Dispatch(ag, []byte{byte(j)}, lst))
+			}
+		}(testAgent(fmt.Sprintf("ch%d", i)))
+	}
+	wg.Wait()
+
+	for id, recv := range lst.recv {
+		assert.Equal(t, count, len(recv), id)
+		for j, b := range recv {
+			assert.Equal(t, byte(j), b, id)
+		}
+	}
+}
+
+func TestPoolDispatcher_Close(t *testing.T) {
+	p := NewPoolDispatcher(1, 1)
+	p.Close()
+	err := p.Dispatch(testAgent("ch"), []byte{1}, &orderListener{})
+	assert.Equal(t, ErrDispatcherClosed, err)
+}
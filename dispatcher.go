@@ -1,8 +1,107 @@
 package HopeIM
 
-import "github.com/sjmshsh/HopeIM/wire/pkt"
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/sjmshsh/HopeIM/wire/pkt"
+)
+
+const (
+	// DefaultDispatchBatchSize 单次推送给一个网关的最大channel数量
+	DefaultDispatchBatchSize = 1000
+	// DefaultDispatchConcurrency 单次Dispatch中并发推送的最大数量
+	DefaultDispatchConcurrency = 16
+)
 
 // Dispather defined a component how a message be dispatched to gateway
 type Dispather interface {
-	Push(gateway string, channels []string, p *pkt.LogicPkt) error
+	Push(ctx context.Context, gateway string, channels []string, p *pkt.LogicPkt) error
+}
+
+// GatewayError 推送到一个网关失败的channels
+type GatewayError struct {
+	Gateway  string
+	Channels []string
+	Err      error
+}
+
+// DispatchError 记录了Dispatch中推送失败的网关及channels，
+// 调用方可以通过errors.As取出它，对失败的部分进行重试或者依赖离线消息
+type DispatchError struct {
+	// Total 本次需要推送的channel总数
+	Total  int
+	Failed []*GatewayError
+}
+
+func (e *DispatchError) Error() string {
+	gateways := make([]string, len(e.Failed))
+	for i, f := range e.Failed {
+		gateways[i] = fmt.Sprintf("%s(%d):%v", f.Gateway, len(f.Channels), f.Err)
+	}
+	return fmt.Sprintf("dispatch failed %d/%d channels: %s", len(e.FailedChannels()), e.Total, strings.Join(gateways, "; "))
+}
+
+// FailedChannels 返回所有推送失败的channel
+func (e *DispatchError) FailedChannels() []string {
+	var channels []string
+	for _, f := range e.Failed {
+		channels = append(channels, f.Channels...)
+	}
+	return channels
+}
+
+// fanout 把packet并发推送到所有的网关中，每个网关的channels按照batch分批推送。
+// 每一次Push都使用一个独立的packet，因为Dispather会在packet中添加meta
+func fanout(ctx context.Context, d Dispather, packet *pkt.LogicPkt, group map[string][]string, batch, concurrency int) error {
+	if batch <= 0 {
+		batch = DefaultDispatchBatchSize
+	}
+	if concurrency <= 0 {
+		concurrency = DefaultDispatchConcurrency
+	}
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		result = &DispatchError{}
+		sem    = make(chan struct{}, concurrency)
+	)
+	for gateway, ids := range group {
+		result.Total += len(ids)
+		for start := 0; start < len(ids); start += batch {
+			end := start + batch
+			if end > len(ids) {
+				end = len(ids)
+			}
+			wg.Add(1)
+			sem <- struct{}{}
+			go func(gateway string, channels []string) {
+				defer func() {
+					<-sem
+					wg.Done()
+				}()
+				p := pkt.NewFrom(&packet.Header)
+				p.Flag = packet.Flag
+				// 复制一份meta，Dispather在p中添加的meta不影响其它的推送
+				p.Meta = append([]*pkt.Meta(nil), packet.Meta...)
+				p.Body = packet.Body
+				if err := d.Push(ctx, gateway, channels, p); err != nil {
+					mu.Lock()
+					result.Failed = append(result.Failed, &GatewayError{
+						Gateway:  gateway,
+						Channels: channels,
+						Err:      err,
+					})
+					mu.Unlock()
+				}
+			}(gateway, ids[start:end])
+		}
+	}
+	wg.Wait()
+	if len(result.Failed) > 0 {
+		return result
+	}
+	return nil
 }
@@ -0,0 +1,113 @@
+package HopeIM
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/sjmshsh/HopeIM/wire"
+	"github.com/sjmshsh/HopeIM/wire/pkt"
+	"github.com/stretchr/testify/assert"
+)
+
+type gatewayDispather struct {
+	sync.Mutex
+	down   map[string]bool
+	pushed map[string][]string
+	calls  int
+}
+
+func (d *gatewayDispather) Push(ctx context.Context, gateway string, channels []string, p *pkt.LogicPkt) error {
+	// the same as the ServerDispather, the packet is modified by dispather
+	p.AddStringMeta(wire.MetaDestChannels, strings.Join(channels, ","))
+	d.Lock()
+	defer d.Unlock()
+	d.calls++
+	if d.down[gateway] {
+		return fmt.Errorf("gateway %s is down", gateway)
+	}
+	if len(p.Meta) != 1 {
+		return fmt.Errorf("packet is shared")
+	}
+	d.pushed[gateway] = append(d.pushed[gateway], channels...)
+	return nil
+}
+
+func TestContext_Dispatch(t *testing.T) {
+	d := &gatewayDispather{
+		down:   map[string]bool{"gate3": true},
+		pushed: make(map[string][]string),
+	}
+	r := NewRouter()
+	r.SetDispatchBatchSize(2)
+	var locs []*Location
+	for i := 0; i < 15; i++ {
+		locs = append(locs, &Location{
+			ChannelId: fmt.Sprintf("ch%02d", i),
+			GateId:    fmt.Sprintf("gate%d", i%4),
+		})
+	}
+	// sender is excluded
+	locs = append(locs, &Location{ChannelId: testSession.ChannelId, GateId: "gate0"})
+
+	var err error
+	r.Handle("chat.group.talk", func(ctx Context) {
+		err = ctx.Dispatch(&pkt.MessagePush{Body: "hello"}, locs...)
+	})
+	_ = r.Serve(pkt.New("chat.group.talk"), d, &testStorage{}, testSession)
+
+	var derr *DispatchError
+	assert.True(t, errors.As(err, &derr))
+	assert.Equal(t, 15, derr.Total)
+	assert.Equal(t, 2, len(derr.Failed))
+	failed := derr.FailedChannels()
+	sort.Strings(failed)
+	assert.Equal(t, []string{"ch03", "ch07", "ch11"}, failed)
+
+	// 4 gateways with 4,4,4,3 channels, in batches of 2
+	assert.Equal(t, 8, d.calls)
+	for _, gateway := range []string{"gate0", "gate1", "gate2"} {
+		assert.Equal(t, 4, len(d.pushed[gateway]), gateway)
+	}
+}
+
+type packetDispather struct {
+	sync.Mutex
+	packets []*pkt.LogicPkt
+}
+
+func (d *packetDispather) Push(ctx context.Context, gateway string, channels []string, p *pkt.LogicPkt) error {
+	d.Lock()
+	defer d.Unlock()
+	d.packets = append(d.packets, p)
+	return nil
+}
+
+func TestContext_DispatchContentType(t *testing.T) {
+	d := &packetDispather{}
+	r := NewRouter()
+	r.Handle("chat.group.talk", func(ctx Context) {
+		_ = ctx.Dispatch(&pkt.MessagePush{Body: "hello"},
+			&Location{ChannelId: "ch4", GateId: "gate1"},
+			&Location{ChannelId: "ch2", GateId: "gate1", ContentType: pkt.ContentType_Protobuf},
+			&Location{ChannelId: "ch3", GateId: "gate2", ContentType: pkt.ContentType_Protobuf},
+		)
+	})
+	_ = r.Serve(pkt.New("chat.group.talk"), d, &testStorage{}, testSession)
+
+	// 每种ContentType的消息体只编码一次，按照接收方的编码推送
+	assert.Equal(t, 3, len(d.packets))
+	types := make(map[pkt.ContentType]int)
+	for _, p := range d.packets {
+		types[p.ContentType]++
+		var push pkt.MessagePush
+		assert.Nil(t, p.ReadBody(&push))
+		assert.Equal(t, "hello", push.Body)
+	}
+	assert.Equal(t, 1, types[pkt.ContentType_Json])
+	assert.Equal(t, 2, types[pkt.ContentType_Protobuf])
+}
@@ -0,0 +1,96 @@
+package HopeIM
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/sjmshsh/HopeIM/logger"
+)
+
+// DrainOptions 服务下线时排空连接的策略
+type DrainOptions struct {
+	// Notify 生成发送给Channel的重连通知，为nil或者返回nil时不发送
+	Notify func(ch Channel) []byte
+	// BatchSize 每一批通知并关闭的连接数
+	BatchSize int
+	// Interval 一批连接从发送通知到被关闭的间隔，也是两批之间的间隔
+	Interval time.Duration
+}
+
+// DefaultDrainOptions DefaultDrainOptions
+func DefaultDrainOptions() DrainOptions {
+	return DrainOptions{
+		BatchSize: DefaultDrainBatchSize,
+		Interval:  DefaultDrainInterval,
+	}
+}
+
+// Drain 分批排空channels中的连接：每一批先发送重连通知，等待Interval之后关闭连接，
+// 使客户端分散地重连到其它节点。ctx结束时立即关闭剩余的所有连接。返回关闭的连接数
+func Drain(ctx context.Context, channels ChannelMap, opts DrainOptions) int {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultDrainBatchSize
+	}
+	log := logger.WithFields(logger.Fields{
+		"module": "drain",
+	})
+	log.Infof("drain %d channels in batches of %d every %v", channels.Count(), opts.BatchSize, opts.Interval)
+
+	var (
+		closed  int
+		handled = make(map[string]struct{}, channels.Count())
+		batch   = make([]Channel, 0, opts.BatchSize)
+	)
+	for {
+		batch = batch[:0]
+		channels.Range(func(ch Channel) bool {
+			if _, ok := handled[ch.ID()]; ok {
+				return true
+			}
+			batch = append(batch, ch)
+			return len(batch) < opts.BatchSize
+		})
+		if len(batch) == 0 {
+			return closed
+		}
+		for _, ch := range batch {
+			handled[ch.ID()] = struct{}{}
+			if opts.Notify == nil {
+				continue
+			}
+			if payload := opts.Notify(ch); payload != nil {
+				_ = ch.Push(payload)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			// 超时，关闭剩余的连接。Range持有分片的读锁，先收集再关闭
+			var rest []Channel
+			channels.Range(func(ch Channel) bool {
+				rest = append(rest, ch)
+				return true
+			})
+			closeAll(rest)
+			closed += len(rest)
+			log.Warnf("drain interrupted: %v, %d channels closed", ctx.Err(), closed)
+			return closed
+		case <-time.After(opts.Interval):
+		}
+		closeAll(batch)
+		closed += len(batch)
+	}
+}
+
+// closeAll 并发关闭连接，Close可能需要等待一个writeWait来发送队列中剩余的消息
+func closeAll(chs []Channel) {
+	var wg sync.WaitGroup
+	wg.Add(len(chs))
+	for _, ch := range chs {
+		go func(ch Channel) {
+			defer wg.Done()
+			_ = ch.Close()
+		}(ch)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,130 @@
+package HopeIM
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recordConn 记录写入的数据及关闭的时间
+type recordConn struct {
+	net.Conn
+	sync.Mutex
+	written  [][]byte
+	closedAt time.Time
+	closed   chan struct{}
+}
+
+func newRecordConn() *recordConn {
+	c1, _ := net.Pipe()
+	return &recordConn{Conn: c1, closed: make(chan struct{})}
+}
+
+func (c *recordConn) ReadFrame() (Frame, error) {
+	<-c.closed
+	return nil, net.ErrClosed
+}
+
+func (c *recordConn) WriteFrame(_ OpCode, payload []byte) error {
+	c.Lock()
+	defer c.Unlock()
+	c.written = append(c.written, payload)
+	return nil
+}
+
+func (c *recordConn) Flush() error { return nil }
+
+func (c *recordConn) SetWriteDeadline(time.Time) error { return nil }
+
+func (c *recordConn) Close() error {
+	c.Lock()
+	defer c.Unlock()
+	if c.closedAt.IsZero() {
+		c.closedAt = time.Now()
+		close(c.closed)
+	}
+	return nil
+}
+
+func (c *recordConn) state() (int, time.Time) {
+	c.Lock()
+	defer c.Unlock()
+	return len(c.written), c.closedAt
+}
+
+func drainChannels(n int) (ChannelMap, []*recordConn) {
+	channels := NewChannels(4)
+	conns := make([]*recordConn, n)
+	for i := 0; i < n; i++ {
+		conns[i] = newRecordConn()
+		channels.Add(NewChannel(fmt.Sprintf("ch%d", i), conns[i]))
+	}
+	return channels, conns
+}
+
+func TestDrain_Batches(t *testing.T) {
+	channels, conns := drainChannels(5)
+
+	start := time.Now()
+	closed := Drain(context.Background(), channels, DrainOptions{
+		Notify: func(ch Channel) []byte {
+			return []byte(ch.ID())
+		},
+		BatchSize: 2,
+		Interval:  time.Millisecond * 20,
+	})
+	assert.Equal(t, 5, closed)
+	// 3批，每批都等待了Interval
+	assert.GreaterOrEqual(t, time.Since(start), time.Millisecond*60)
+
+	batches := make(map[time.Time]int)
+	for _, conn := range conns {
+		assert.Eventually(t, func() bool {
+			written, _ := conn.state()
+			return written == 1
+		}, time.Second, time.Millisecond)
+		_, closedAt := conn.state()
+		assert.False(t, closedAt.IsZero())
+		batches[closedAt.Truncate(time.Millisecond*10)]++
+	}
+	assert.GreaterOrEqual(t, len(batches), 3)
+}
+
+func TestDrain_Deadline(t *testing.T) {
+	channels, conns := drainChannels(5)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+	start := time.Now()
+	closed := Drain(ctx, channels, DrainOptions{
+		BatchSize: 1,
+		Interval:  time.Second,
+	})
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, 5, closed)
+	for _, conn := range conns {
+		_, closedAt := conn.state()
+		assert.False(t, closedAt.IsZero())
+	}
+}
+
+func TestDrain_CloseInParallel(t *testing.T) {
+	channels := NewChannels(4)
+	for i := 0; i < 5; i++ {
+		ch := NewChannel(fmt.Sprintf("ch%d", i), newStalledConn())
+		// 对端不读取数据，每个Close都要等待一个writeWait
+		fillQueue(t, ch, 2)
+		channels.Add(ch)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	start := time.Now()
+	closed := Drain(ctx, channels, DrainOptions{BatchSize: 1, Interval: time.Second})
+	assert.Equal(t, 5, closed)
+	assert.Less(t, time.Since(start), time.Millisecond*300)
+}
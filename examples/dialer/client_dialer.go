@@ -2,12 +2,12 @@ package dialer
 
 import (
 	"bytes"
-	"context"
 	"fmt"
 	"github.com/gobwas/ws"
 	"github.com/gobwas/ws/wsutil"
 	"github.com/sjmshsh/HopeIM"
 	"github.com/sjmshsh/HopeIM/logger"
+	"github.com/sjmshsh/HopeIM/websocket"
 	"github.com/sjmshsh/HopeIM/wire"
 	"github.com/sjmshsh/HopeIM/wire/pkt"
 	"github.com/sjmshsh/HopeIM/wire/token"
@@ -17,11 +17,15 @@ import (
 
 type ClientDialer struct {
 	AppSecret string
+	// ContentType 登录时协商的消息体编码，之后服务端推送的消息使用它编码
+	ContentType pkt.ContentType
+	// Device 登录的设备类型，服务端按照它执行多设备登录策略
+	Device string
 }
 
 func (d *ClientDialer) DialAndHandshake(ctx HopeIM.DialerContext) (net.Conn, error) {
-	// 1. 拨号
-	conn, _, _, err := ws.Dial(context.TODO(), ctx.Address)
+	// 1. 拨号，wss://地址使用TLS
+	conn, err := websocket.Dial(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -38,8 +42,9 @@ func (d *ClientDialer) DialAndHandshake(ctx HopeIM.DialerContext) (net.Conn, err
 		return nil, err
 	}
 	// 3. 发送一条CommandLoginSignIn消息
-	loginreq := pkt.New(wire.CommandLoginSignIn).WriteBody(&pkt.LoginReq{
-		Token: tk,
+	loginreq := pkt.New(wire.CommandLoginSignIn, pkt.WithContentType(d.ContentType)).WriteBody(&pkt.LoginReq{
+		Token:  tk,
+		Device: d.Device,
 	})
 	err = wsutil.WriteClientBinary(conn, pkt.Marshal(loginreq))
 	if err != nil {
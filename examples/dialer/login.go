@@ -6,7 +6,7 @@ import (
 	"github.com/sjmshsh/HopeIM/wire/token"
 )
 
-func Login(wsurl, account string, appSecrets ...string) (HopeIM.Client, error) {
+func Login(wsurl, account string, appSecrets ...string) (HopeIM.RequestClient, error) {
 	cli := websocket.NewClient(account, "unittest", websocket.ClientOptions{})
 	secret := token.DefaultSecret
 	if len(appSecrets) > 0 {
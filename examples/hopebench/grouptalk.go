@@ -1,7 +1,7 @@
 package hopebench
 
 import (
-	"bytes"
+	"context"
 	"fmt"
 	"github.com/panjf2000/ants/v2"
 	"github.com/sjmshsh/HopeIM"
@@ -30,12 +30,12 @@ func grouptalk(wsurl, appSecret string, threads, count int, memberCount int, onl
 		Owner:   "test1",
 		Members: members,
 	})
-	if err = cli1.Send(pkt.Marshal(p)); err != nil {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+	ackp, err := cli1.Request(ctx, p)
+	if err != nil {
 		return err
 	}
-	// 读取返回信息
-	ack, _ := cli1.Read()
-	ackp, _ := pkt.MustReadLogicPkt(bytes.NewBuffer(ack.GetPayload()))
 	if pkt.Status_Success != ackp.GetStatus() {
 		return fmt.Errorf("create group failed")
 	}
@@ -88,17 +88,13 @@ func grouptalk(wsurl, appSecret string, threads, count int, memberCount int, onl
 				Type: 1,
 				Body: "hello world",
 			})
-			// 发送消息
-			err := cli.Send(pkt.Marshal(p))
-			if err != nil {
-				r.Add(&report.Result{
-					Err:           err,
-					ContentLength: 11,
-				})
-				return
+			// 发送消息并等待Resp
+			ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+			defer cancel()
+			resp, err := cli.Request(ctx, p)
+			if err == nil && resp.GetStatus() != pkt.Status_Success {
+				err = fmt.Errorf("%s: %v", resp.GetCommand(), resp.GetStatus())
 			}
-			// 读取Resp消息
-			_, err = cli.Read()
 			if err != nil {
 				r.Add(&report.Result{
 					Err:           err,
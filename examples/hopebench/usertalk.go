@@ -1,6 +1,7 @@
 package hopebench
 
 import (
+	"context"
 	"fmt"
 	"github.com/panjf2000/ants/v2"
 	"github.com/sjmshsh/HopeIM"
@@ -13,8 +14,8 @@ import (
 	"time"
 )
 
-func loginMulti(wsurl, appSecret string, start, count int) ([]HopeIM.Client, error) {
-	clis := make([]HopeIM.Client, count)
+func loginMulti(wsurl, appSecret string, start, count int) ([]HopeIM.RequestClient, error) {
+	clis := make([]HopeIM.RequestClient, count)
 	for i := 0; i < count; i++ {
 		account := fmt.Sprintf("test%d", start)
 		start++
@@ -27,6 +28,9 @@ func loginMulti(wsurl, appSecret string, start, count int) ([]HopeIM.Client, err
 	return clis, nil
 }
 
+// requestTimeout 压测中单个请求的超时时间
+const requestTimeout = time.Second * 10
+
 func usertalk(wsurl, appSecret string, threads, count int, online bool) error {
 	p, _ := ants.NewPool(threads, ants.WithPreAlloc(true))
 	defer p.Release()
@@ -67,22 +71,19 @@ func usertalk(wsurl, appSecret string, threads, count int, online bool) error {
 				Type: 1,
 				Body: "hello world",
 			})
-			// 发送消息
-			err := cli.Send(pkt.Marshal(p))
-			if err != nil {
-				r.Add(&report.Result{
-					Err:           err,
-					ContentLength: 11,
-				})
-				return
+			// 发送消息并等待Resp
+			ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+			defer cancel()
+			resp, err := cli.Request(ctx, p)
+			if err == nil && resp.GetStatus() != pkt.Status_Success {
+				err = fmt.Errorf("%s: %v", resp.GetCommand(), resp.GetStatus())
 			}
-			// 读取Resp消息
-			_, err = cli.Read()
 			if err != nil {
 				r.Add(&report.Result{
 					Err:           err,
 					ContentLength: 11,
 				})
+				return
 			}
 			r.Add(&report.Result{
 				Duration:   time.Since(t0),
@@ -0,0 +1,25 @@
+package HopeIM
+
+import (
+	"errors"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/sjmshsh/HopeIM/wire"
+)
+
+var limitExceeded = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "hopeim",
+	Name:      "limit_exceeded_total",
+	Help:      "number of frames or packets rejected by the size limits",
+}, []string{"field"})
+
+// CountLimitExceeded 如果err是一个wire.LimitError就计数并返回true，调用方应该关闭对应的channel
+func CountLimitExceeded(err error) bool {
+	var lerr *wire.LimitError
+	if !errors.As(err, &lerr) {
+		return false
+	}
+	limitExceeded.WithLabelValues(lerr.Field).Inc()
+	return true
+}
@@ -4,12 +4,17 @@ import (
 	"bytes"
 	"errors"
 	"github.com/sjmshsh/HopeIM/wire/endian"
+	"github.com/sjmshsh/HopeIM/wire/pkt"
 )
 
 // Location 表示一个用户的位置，网关ID和ChannelId
 type Location struct {
 	ChannelId string
 	GateId    string
+	// ContentType 推送给这个用户的消息体编码
+	ContentType pkt.ContentType
+	// Device 登录的设备类型，一个账号的每个设备都有一个Location
+	Device string
 }
 
 func (loc *Location) Bytes() []byte {
@@ -19,6 +24,8 @@ func (loc *Location) Bytes() []byte {
 	buf := new(bytes.Buffer)
 	_ = endian.WriteShortBytes(buf, []byte(loc.ChannelId))
 	_ = endian.WriteShortBytes(buf, []byte(loc.GateId))
+	_ = endian.WriteUint8(buf, uint8(loc.ContentType))
+	_ = endian.WriteShortBytes(buf, []byte(loc.Device))
 	return buf.Bytes()
 }
 
@@ -35,5 +42,17 @@ func (loc *Location) Unmarshal(data []byte) (err error) {
 	if err != nil {
 		return
 	}
+	// 兼容没有ContentType与Device的旧数据
+	if buf.Len() > 0 {
+		var contentType uint8
+		contentType, err = endian.ReadUint8(buf)
+		if err != nil {
+			return
+		}
+		loc.ContentType = pkt.ContentType(contentType)
+	}
+	if buf.Len() > 0 {
+		loc.Device, err = endian.ReadShortString(buf)
+	}
 	return err
 }
@@ -0,0 +1,32 @@
+package HopeIM
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/sjmshsh/HopeIM/wire"
+	"github.com/sjmshsh/HopeIM/wire/endian"
+	"github.com/sjmshsh/HopeIM/wire/pkt"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocation_Unmarshal(t *testing.T) {
+	loc := &Location{ChannelId: "ch1", GateId: "gate1", ContentType: pkt.ContentType_Protobuf, Device: wire.DeviceIOS}
+	var got Location
+	assert.Nil(t, got.Unmarshal(loc.Bytes()))
+	assert.Equal(t, *loc, got)
+
+	// 旧版本保存的Location没有ContentType
+	buf := new(bytes.Buffer)
+	_ = endian.WriteShortBytes(buf, []byte("ch1"))
+	_ = endian.WriteShortBytes(buf, []byte("gate1"))
+	got = Location{}
+	assert.Nil(t, got.Unmarshal(buf.Bytes()))
+	assert.Equal(t, Location{ChannelId: "ch1", GateId: "gate1"}, got)
+
+	// 没有Device的数据
+	_ = endian.WriteUint8(buf, uint8(pkt.ContentType_Protobuf))
+	got = Location{}
+	assert.Nil(t, got.Unmarshal(buf.Bytes()))
+	assert.Equal(t, Location{ChannelId: "ch1", GateId: "gate1", ContentType: pkt.ContentType_Protobuf}, got)
+}
@@ -0,0 +1,53 @@
+package HopeIM
+
+import (
+	"sort"
+
+	"github.com/sjmshsh/HopeIM/wire"
+	"github.com/sjmshsh/HopeIM/wire/pkt"
+)
+
+// PresenceStorage 保存账号的自定义状态与在线状态的订阅关系，
+// 账号是否在线由SessionStorage中的Location决定
+type PresenceStorage interface {
+	// SetStatus 设置自定义状态，status为空时清除
+	SetStatus(account string, status string) error
+	// GetStatus 返回账号的自定义状态，没有设置的账号不在结果中
+	GetStatus(accounts ...string) (map[string]string, error)
+	// Subscribe subscriber订阅accounts的在线状态变化
+	Subscribe(subscriber string, accounts ...string) error
+	Unsubscribe(subscriber string, accounts ...string) error
+	// Subscribers 返回订阅了account的账号
+	Subscribers(account string) ([]string, error)
+	// Touch 刷新在线账号的自定义状态、订阅者及它订阅的关系的过期时间
+	Touch(accounts ...string) error
+}
+
+// PresenceOf 根据账号所有设备的Location与自定义状态计算在线状态，
+// 没有Location时为offline，否则自定义状态优先
+func PresenceOf(account string, locs []*Location, status string) *pkt.Presence {
+	presence := &pkt.Presence{
+		Account: account,
+		Status:  wire.PresenceOffline,
+	}
+	if len(locs) == 0 {
+		return presence
+	}
+	presence.Status = wire.PresenceOnline
+	if status != "" {
+		presence.Status = status
+	}
+	devices := make(map[string]struct{}, len(locs))
+	for _, loc := range locs {
+		if loc.Device == "" {
+			continue
+		}
+		if _, ok := devices[loc.Device]; ok {
+			continue
+		}
+		devices[loc.Device] = struct{}{}
+		presence.Devices = append(presence.Devices, loc.Device)
+	}
+	sort.Strings(presence.Devices)
+	return presence
+}
@@ -0,0 +1,28 @@
+package HopeIM
+
+import (
+	"testing"
+
+	"github.com/sjmshsh/HopeIM/wire"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPresenceOf(t *testing.T) {
+	p := PresenceOf("test1", nil, wire.PresenceBusy)
+	assert.Equal(t, wire.PresenceOffline, p.Status)
+	assert.Empty(t, p.Devices)
+
+	locs := []*Location{
+		{ChannelId: "ch1", Device: wire.DevicePC},
+		{ChannelId: "ch2", Device: wire.DeviceIOS},
+		{ChannelId: "ch3", Device: wire.DevicePC},
+		{ChannelId: "ch4"},
+	}
+	p = PresenceOf("test1", locs, "")
+	assert.Equal(t, "test1", p.Account)
+	assert.Equal(t, wire.PresenceOnline, p.Status)
+	assert.Equal(t, []string{wire.DeviceIOS, wire.DevicePC}, p.Devices)
+
+	p = PresenceOf("test1", locs, wire.PresenceAway)
+	assert.Equal(t, wire.PresenceAway, p.Status)
+}
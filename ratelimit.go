@@ -0,0 +1,150 @@
+package HopeIM
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/sjmshsh/HopeIM/logger"
+	"github.com/sjmshsh/HopeIM/wire/pkt"
+	"golang.org/x/time/rate"
+)
+
+var ErrTooManyRequests = errors.New("err:too many requests")
+
+// RateLimit 令牌桶的参数，Rate为每秒生成的令牌数，Burst为桶的容量。Rate<=0表示不限制
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+// RateLimitRule 一个令牌桶，同一个Key共享一个令牌桶
+type RateLimitRule struct {
+	Key   string
+	Limit RateLimit
+}
+
+// RateLimiter 限流器
+type RateLimiter interface {
+	// Allow 所有规则的令牌桶都有令牌时各取一个令牌并返回true；
+	// 任意一个没有令牌时返回false，不消耗其它令牌桶的令牌
+	Allow(ctx context.Context, rules ...RateLimitRule) (bool, error)
+}
+
+// RateLimitOptions 限流规则，每个请求需要同时通过所有配置的规则
+type RateLimitOptions struct {
+	// Account 单个账号所有指令的限流
+	Account RateLimit
+	// App 单个应用下所有账号的限流
+	App RateLimit
+	// Commands 单个账号指定指令的限流
+	Commands map[string]RateLimit
+}
+
+// RateLimitMiddleware 按照account、app及command限流的中间件，超过限制时返回Status_TooManyRequests。
+// 没有account的会话(如登录指令)不限流；限流器出错时放行请求
+func RateLimitMiddleware(limiter RateLimiter, opts RateLimitOptions) HandlerFunc {
+	return func(ctx Context) {
+		session := ctx.Session()
+		if session == nil || session.GetAccount() == "" {
+			ctx.Next()
+			return
+		}
+		command := ctx.Header().GetCommand()
+		candidates := [3]RateLimitRule{
+			{"cmd:" + command + ":" + session.GetAccount(), opts.Commands[command]},
+			{"account:" + session.GetAccount(), opts.Account},
+			{"app:" + session.GetApp(), opts.App},
+		}
+		rules := make([]RateLimitRule, 0, len(candidates))
+		for _, rule := range candidates {
+			if rule.Limit.Rate > 0 {
+				rules = append(rules, rule)
+			}
+		}
+		if len(rules) == 0 {
+			ctx.Next()
+			return
+		}
+		ok, err := limiter.Allow(ctx, rules...)
+		if err != nil {
+			logger.WithFields(logger.Fields{
+				"module":  "ratelimit",
+				"command": command,
+			}).Warn(err)
+			ctx.Next()
+			return
+		}
+		if !ok {
+			_ = ctx.RespWithError(pkt.Status_TooManyRequests, ErrTooManyRequests)
+			ctx.Abort()
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// MemoryRateLimiter 单机的RateLimiter
+type MemoryRateLimiter struct {
+	sync.Mutex
+	buckets map[string]*memoryBucket
+	idle    time.Duration
+	swept   time.Time
+}
+
+type memoryBucket struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// NewMemoryRateLimiter NewMemoryRateLimiter, 超过idle没有被访问的令牌桶会被清理
+func NewMemoryRateLimiter(idle time.Duration) *MemoryRateLimiter {
+	if idle <= 0 {
+		idle = DefaultRateLimitIdle
+	}
+	return &MemoryRateLimiter{
+		buckets: make(map[string]*memoryBucket),
+		idle:    idle,
+		swept:   time.Now(),
+	}
+}
+
+// Allow Allow
+func (m *MemoryRateLimiter) Allow(_ context.Context, rules ...RateLimitRule) (bool, error) {
+	now := time.Now()
+	m.Lock()
+	defer m.Unlock()
+	if now.Sub(m.swept) > m.idle {
+		for k, b := range m.buckets {
+			if now.Sub(b.lastSeen) > m.idle {
+				delete(m.buckets, k)
+			}
+		}
+		m.swept = now
+	}
+	reservations := make([]*rate.Reservation, 0, len(rules))
+	for _, rule := range rules {
+		b, ok := m.buckets[rule.Key]
+		if !ok {
+			burst := rule.Limit.Burst
+			if burst < 1 {
+				burst = 1
+			}
+			b = &memoryBucket{limiter: rate.NewLimiter(rate.Limit(rule.Limit.Rate), burst)}
+			m.buckets[rule.Key] = b
+		}
+		b.lastSeen = now
+		r := b.limiter.ReserveN(now, 1)
+		if !r.OK() || r.DelayFrom(now) > 0 {
+			// 归还已经取出的令牌
+			r.CancelAt(now)
+			for _, taken := range reservations {
+				taken.CancelAt(now)
+			}
+			return false, nil
+		}
+		reservations = append(reservations, r)
+	}
+	return true, nil
+}
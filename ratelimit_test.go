@@ -0,0 +1,68 @@
+package HopeIM
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sjmshsh/HopeIM/wire/pkt"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimitMiddleware(t *testing.T) {
+	r := NewRouter()
+	r.Use(RateLimitMiddleware(NewMemoryRateLimiter(time.Minute), RateLimitOptions{
+		Account: RateLimit{Rate: 0.001, Burst: 2},
+		Commands: map[string]RateLimit{
+			"chat.user.talk": {Rate: 0.001, Burst: 1},
+		},
+	}))
+	served := 0
+	r.Handle("chat.user.talk", func(ctx Context) { served++ })
+	r.Handle("chat.group.talk", func(ctx Context) { served++ })
+
+	d := serve(r, "chat.user.talk")
+	assert.Empty(t, d.packets)
+	// 超过指令的限制
+	d = serve(r, "chat.user.talk")
+	assert.Len(t, d.packets, 1)
+	assert.Equal(t, pkt.Status_TooManyRequests, d.packets[0].Status)
+
+	// 其它指令只受到账号的限制，被指令拒绝的请求不消耗账号的令牌
+	d = serve(r, "chat.group.talk")
+	assert.Empty(t, d.packets)
+	d = serve(r, "chat.group.talk")
+	assert.Len(t, d.packets, 1)
+	assert.Equal(t, pkt.Status_TooManyRequests, d.packets[0].Status)
+	assert.Equal(t, 2, served)
+}
+
+func TestMemoryRateLimiter_AllOrNothing(t *testing.T) {
+	m := NewMemoryRateLimiter(time.Minute)
+	ctx := context.Background()
+	limit := RateLimit{Rate: 0.001, Burst: 1}
+
+	ok, _ := m.Allow(ctx, RateLimitRule{"app", limit})
+	assert.True(t, ok)
+	// app的令牌桶已经空了，被拒绝的请求不消耗cmd与account的令牌
+	ok, _ = m.Allow(ctx, RateLimitRule{"cmd", limit}, RateLimitRule{"account", limit}, RateLimitRule{"app", limit})
+	assert.False(t, ok)
+	ok, _ = m.Allow(ctx, RateLimitRule{"cmd", limit}, RateLimitRule{"account", limit})
+	assert.True(t, ok)
+}
+
+func TestMemoryRateLimiter_Sweep(t *testing.T) {
+	m := NewMemoryRateLimiter(time.Millisecond * 10)
+	limit := RateLimit{Rate: 0.001, Burst: 1}
+	ok, _ := m.Allow(context.Background(), RateLimitRule{"a", limit})
+	assert.True(t, ok)
+	ok, _ = m.Allow(context.Background(), RateLimitRule{"a", limit})
+	assert.False(t, ok)
+
+	time.Sleep(time.Millisecond * 20)
+	_, _ = m.Allow(context.Background(), RateLimitRule{"b", limit})
+	m.Lock()
+	_, exist := m.buckets["a"]
+	m.Unlock()
+	assert.False(t, exist)
+}
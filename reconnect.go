@@ -0,0 +1,305 @@
+package HopeIM
+
+import (
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/sjmshsh/HopeIM/logger"
+)
+
+const (
+	DefaultReconnectMinBackoff = time.Millisecond * 200
+	DefaultReconnectMaxBackoff = time.Second * 30
+	DefaultReconnectMultiplier = 2
+	DefaultReconnectJitter     = 0.2
+)
+
+var (
+	ErrClientClosed     = errors.New("err:client closed")
+	ErrReconnecting     = errors.New("err:client is reconnecting")
+	ErrReconnectGiveUp  = errors.New("err:reconnect attempts exhausted")
+	ErrReconnectBufFull = errors.New("err:reconnect buffer is full")
+)
+
+// ConnState 客户端连接的状态
+type ConnState int32
+
+const (
+	ConnStateConnecting ConnState = iota + 1
+	ConnStateConnected
+	ConnStateDisconnected
+)
+
+func (s ConnState) String() string {
+	switch s {
+	case ConnStateConnecting:
+		return "connecting"
+	case ConnStateConnected:
+		return "connected"
+	case ConnStateDisconnected:
+		return "disconnected"
+	}
+	return "unknown"
+}
+
+// ReconnectOptions 客户端断线重连的配置
+type ReconnectOptions struct {
+	// MinBackoff 第一次重试之前的等待时间，之后每次乘以Multiplier，最多为MaxBackoff
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	Multiplier float64
+	// Jitter 等待时间随机浮动的比例，避免大量客户端同时重连
+	Jitter float64
+	// MaxAttempts 连续失败的最大次数，0表示一直重试
+	MaxAttempts int
+	// BufferSize 重连期间缓存的待发送帧数量，重连成功之后按顺序发送；0表示不缓存，Send返回ErrReconnecting
+	BufferSize int
+	// OnStateChange 连接状态变化时回调，err是导致变化的原因
+	OnStateChange func(state ConnState, err error)
+}
+
+// DefaultReconnectOptions DefaultReconnectOptions
+func DefaultReconnectOptions() ReconnectOptions {
+	return ReconnectOptions{
+		MinBackoff: DefaultReconnectMinBackoff,
+		MaxBackoff: DefaultReconnectMaxBackoff,
+		Multiplier: DefaultReconnectMultiplier,
+		Jitter:     DefaultReconnectJitter,
+	}
+}
+
+// Backoff 第attempt次(从0开始)重试之前的等待时间
+func (o ReconnectOptions) Backoff(attempt int) time.Duration {
+	backoff := float64(o.MinBackoff)
+	for i := 0; i < attempt && backoff < float64(o.MaxBackoff); i++ {
+		backoff *= o.Multiplier
+	}
+	if backoff > float64(o.MaxBackoff) {
+		backoff = float64(o.MaxBackoff)
+	}
+	if o.Jitter > 0 {
+		backoff += backoff * o.Jitter * (rand.Float64()*2 - 1)
+	}
+	return time.Duration(backoff)
+}
+
+// Reconnector 管理客户端的连接状态与断线重连，tcp与websocket的Client共用。
+// 每次连接成功之后代数加一，客户端在读写出错时通过代数报告是哪一个连接断开了，
+// 这样同一个连接上的多个错误只会触发一次重连
+type Reconnector struct {
+	sync.Mutex
+	opts ReconnectOptions
+	// dial 使用第gen代建立连接并完成握手，成功之后客户端替换当前的连接
+	dial func(gen uint64) error
+	// write 在当前连接上写入一帧，用于发送缓存的数据
+	write func(payload []byte) error
+
+	gen    uint64
+	state  ConnState
+	err    error
+	ready  chan struct{}
+	buffer [][]byte
+	closed chan struct{}
+}
+
+// NewReconnector NewReconnector
+func NewReconnector(opts ReconnectOptions, dial func(gen uint64) error, write func(payload []byte) error) *Reconnector {
+	def := DefaultReconnectOptions()
+	if opts.MinBackoff <= 0 {
+		opts.MinBackoff = def.MinBackoff
+	}
+	if opts.MaxBackoff < opts.MinBackoff {
+		opts.MaxBackoff = def.MaxBackoff
+	}
+	if opts.Multiplier < 1 {
+		opts.Multiplier = def.Multiplier
+	}
+	return &Reconnector{
+		opts:   opts,
+		dial:   dial,
+		write:  write,
+		ready:  make(chan struct{}),
+		closed: make(chan struct{}),
+	}
+}
+
+// Connect 建立第一个连接，失败时直接返回错误，不会重试
+func (r *Reconnector) Connect() error {
+	r.setState(ConnStateConnecting, nil)
+	r.Lock()
+	r.gen++
+	gen := r.gen
+	r.Unlock()
+	if err := r.dial(gen); err != nil {
+		r.setState(ConnStateDisconnected, err)
+		return err
+	}
+	r.Lock()
+	close(r.ready)
+	r.Unlock()
+	r.setState(ConnStateConnected, nil)
+	return nil
+}
+
+// State 当前的状态
+func (r *Reconnector) State() ConnState {
+	r.Lock()
+	defer r.Unlock()
+	return r.state
+}
+
+// Disconnected 第gen代连接出错，如果它还是当前的连接就开始重连
+func (r *Reconnector) Disconnected(gen uint64, err error) {
+	r.Lock()
+	if gen != r.gen || r.state != ConnStateConnected {
+		r.Unlock()
+		return
+	}
+	r.state = ConnStateConnecting
+	r.ready = make(chan struct{})
+	r.Unlock()
+	r.notify(ConnStateConnecting, err)
+	go r.reconnect(err)
+}
+
+// Wait 等待重连结束，返回nil表示已经重新连接
+func (r *Reconnector) Wait() error {
+	for {
+		r.Lock()
+		state, err, ready := r.state, r.err, r.ready
+		r.Unlock()
+		switch state {
+		case ConnStateConnected:
+			return nil
+		case ConnStateDisconnected:
+			return err
+		}
+		<-ready
+	}
+}
+
+// Buffer 重连期间缓存一帧数据。已经连接时返回false，调用方直接写入连接；
+// 不缓存、缓存已满或者已经放弃重连时返回错误
+func (r *Reconnector) Buffer(payload []byte) (bool, error) {
+	r.Lock()
+	defer r.Unlock()
+	switch r.state {
+	case ConnStateConnected:
+		return false, nil
+	case ConnStateDisconnected:
+		return false, r.err
+	}
+	if r.opts.BufferSize == 0 {
+		return false, ErrReconnecting
+	}
+	if len(r.buffer) >= r.opts.BufferSize {
+		return false, ErrReconnectBufFull
+	}
+	// payload可能来自调用方复用的buffer
+	r.buffer = append(r.buffer, append([]byte(nil), payload...))
+	return true, nil
+}
+
+// Close 停止重连，等待中的Wait返回ErrClientClosed
+func (r *Reconnector) Close() {
+	r.Lock()
+	select {
+	case <-r.closed:
+		r.Unlock()
+		return
+	default:
+	}
+	close(r.closed)
+	r.Unlock()
+	r.giveUp(ErrClientClosed)
+}
+
+func (r *Reconnector) reconnect(cause error) {
+	log := logger.WithField("module", "reconnector")
+	for attempt := 0; r.opts.MaxAttempts == 0 || attempt < r.opts.MaxAttempts; attempt++ {
+		backoff := r.opts.Backoff(attempt)
+		log.Infof("reconnect in %v, attempt %d: %v", backoff, attempt+1, cause)
+		select {
+		case <-r.closed:
+			return
+		case <-time.After(backoff):
+		}
+		r.Lock()
+		r.gen++
+		gen := r.gen
+		r.Unlock()
+		if cause = r.dial(gen); cause != nil {
+			continue
+		}
+		if cause = r.flush(); cause != nil {
+			continue
+		}
+		return
+	}
+	log.Warnf("give up reconnecting: %v", cause)
+	r.giveUp(ErrReconnectGiveUp)
+}
+
+// flush 按顺序发送缓存的数据，缓存为空时才切换为connected，保证之后的Send不会越过缓存的数据
+func (r *Reconnector) flush() error {
+	for {
+		r.Lock()
+		select {
+		case <-r.closed:
+			r.Unlock()
+			return nil
+		default:
+		}
+		if len(r.buffer) == 0 {
+			r.state = ConnStateConnected
+			close(r.ready)
+			r.Unlock()
+			r.notify(ConnStateConnected, nil)
+			return nil
+		}
+		payload := r.buffer[0]
+		r.Unlock()
+		if err := r.write(payload); err != nil {
+			return err
+		}
+		r.Lock()
+		r.buffer = r.buffer[1:]
+		r.Unlock()
+	}
+}
+
+func (r *Reconnector) giveUp(err error) {
+	r.Lock()
+	if r.state == ConnStateDisconnected {
+		r.Unlock()
+		return
+	}
+	r.state = ConnStateDisconnected
+	r.err = err
+	r.buffer = nil
+	select {
+	case <-r.ready:
+	default:
+		close(r.ready)
+	}
+	r.Unlock()
+	r.notify(ConnStateDisconnected, err)
+}
+
+func (r *Reconnector) setState(state ConnState, err error) {
+	r.Lock()
+	r.state = state
+	if state == ConnStateDisconnected {
+		r.err = err
+	}
+	r.Unlock()
+	r.notify(state, err)
+}
+
+func (r *Reconnector) notify(state ConnState, err error) {
+	if r.opts.OnStateChange != nil {
+		r.opts.OnStateChange(state, err)
+	}
+}
@@ -0,0 +1,124 @@
+package HopeIM
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReconnectOptions_Backoff(t *testing.T) {
+	opts := ReconnectOptions{
+		MinBackoff: time.Millisecond * 100,
+		MaxBackoff: time.Second,
+		Multiplier: 2,
+	}
+	assert.Equal(t, time.Millisecond*100, opts.Backoff(0))
+	assert.Equal(t, time.Millisecond*400, opts.Backoff(2))
+	assert.Equal(t, time.Second, opts.Backoff(10))
+
+	opts.Jitter = 0.2
+	for i := 0; i < 100; i++ {
+		backoff := opts.Backoff(1)
+		assert.GreaterOrEqual(t, backoff, time.Millisecond*160)
+		assert.LessOrEqual(t, backoff, time.Millisecond*240)
+	}
+}
+
+type testLink struct {
+	sync.Mutex
+	fails   int
+	dials   int
+	written []string
+	states  []ConnState
+}
+
+func (l *testLink) dial(gen uint64) error {
+	l.Lock()
+	defer l.Unlock()
+	l.dials++
+	if l.fails > 0 {
+		l.fails--
+		return errors.New("dial failed")
+	}
+	return nil
+}
+
+func (l *testLink) write(payload []byte) error {
+	l.Lock()
+	defer l.Unlock()
+	l.written = append(l.written, string(payload))
+	return nil
+}
+
+func (l *testLink) options() ReconnectOptions {
+	return ReconnectOptions{
+		MinBackoff: time.Millisecond,
+		MaxBackoff: time.Millisecond * 5,
+		BufferSize: 2,
+		OnStateChange: func(state ConnState, err error) {
+			l.Lock()
+			l.states = append(l.states, state)
+			l.Unlock()
+		},
+	}
+}
+
+func TestReconnector(t *testing.T) {
+	link := &testLink{}
+	r := NewReconnector(link.options(), link.dial, link.write)
+	assert.Nil(t, r.Connect())
+	assert.Equal(t, ConnStateConnected, r.State())
+
+	buffered, err := r.Buffer([]byte("direct"))
+	assert.False(t, buffered)
+	assert.Nil(t, err)
+
+	link.Lock()
+	link.fails = 2
+	link.Unlock()
+	r.Disconnected(1, errors.New("broken pipe"))
+	// 同一个连接上的错误只触发一次重连
+	r.Disconnected(1, errors.New("broken pipe"))
+
+	payload := []byte("m1")
+	buffered, _ = r.Buffer(payload)
+	assert.True(t, buffered)
+	// 缓存的是副本
+	payload[1] = '0'
+	_, _ = r.Buffer([]byte("m2"))
+	_, err = r.Buffer([]byte("m3"))
+	assert.Equal(t, ErrReconnectBufFull, err)
+
+	assert.Nil(t, r.Wait())
+	link.Lock()
+	assert.Equal(t, 4, link.dials)
+	assert.Equal(t, []string{"m1", "m2"}, link.written)
+	assert.Equal(t, []ConnState{ConnStateConnecting, ConnStateConnected, ConnStateConnecting, ConnStateConnected}, link.states)
+	link.Unlock()
+
+	r.Close()
+	assert.Equal(t, ErrClientClosed, r.Wait())
+	_, err = r.Buffer([]byte("m4"))
+	assert.Equal(t, ErrClientClosed, err)
+}
+
+func TestReconnector_GiveUp(t *testing.T) {
+	link := &testLink{}
+	opts := link.options()
+	opts.MaxAttempts = 3
+	r := NewReconnector(opts, link.dial, link.write)
+	assert.Nil(t, r.Connect())
+
+	link.Lock()
+	link.fails = 10
+	link.Unlock()
+	r.Disconnected(1, errors.New("broken pipe"))
+	assert.Equal(t, ErrReconnectGiveUp, r.Wait())
+	assert.Equal(t, ConnStateDisconnected, r.State())
+	link.Lock()
+	assert.Equal(t, 4, link.dials)
+	link.Unlock()
+}
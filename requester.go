@@ -0,0 +1,181 @@
+package HopeIM
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/sjmshsh/HopeIM/logger"
+	"github.com/sjmshsh/HopeIM/wire"
+	"github.com/sjmshsh/HopeIM/wire/pkt"
+)
+
+var (
+	ErrRequesterClosed   = errors.New("err:requester closed")
+	ErrDuplicateSequence = errors.New("err:sequence is already in flight")
+)
+
+// PushHandler 处理服务端主动推送(Flag_Push)的消息
+type PushHandler func(p *pkt.LogicPkt)
+
+// RequestClient 在Client的基础上提供请求与响应的关联能力
+type RequestClient interface {
+	Client
+	// Request 发送一个请求，并等待Sequence相同的Flag_Response响应
+	Request(ctx context.Context, p *pkt.LogicPkt) (*pkt.LogicPkt, error)
+	// SetPushHandler 设置推送消息的回调
+	SetPushHandler(handler PushHandler)
+}
+
+// Requester 基于Client的Send与Read实现请求响应的关联。
+// 一旦启动，Client的读操作就由Requester接管，调用方不能再直接调用Client.Read。
+type Requester struct {
+	sync.Mutex
+	cli     Client
+	once    sync.Once
+	pending map[uint32]chan *pkt.LogicPkt
+	push    PushHandler
+	done    chan struct{}
+	err     error
+}
+
+// NewRequester NewRequester
+func NewRequester(cli Client) *Requester {
+	return &Requester{
+		cli:     cli,
+		pending: make(map[uint32]chan *pkt.LogicPkt),
+		done:    make(chan struct{}),
+	}
+}
+
+// SetPushHandler 设置推送消息的回调，并启动读循环
+func (r *Requester) SetPushHandler(handler PushHandler) {
+	r.Lock()
+	r.push = handler
+	r.Unlock()
+	r.start()
+}
+
+// Request 发送请求并等待响应，支持多个请求同时在途。
+// 如果p.Sequence为0，会自动分配一个；响应的Status由调用方自行判断。
+func (r *Requester) Request(ctx context.Context, p *pkt.LogicPkt) (*pkt.LogicPkt, error) {
+	r.start()
+	if p.Sequence == 0 {
+		p.Sequence = wire.Seq.Next()
+	}
+	seq := p.Sequence
+	wait := make(chan *pkt.LogicPkt, 1)
+
+	r.Lock()
+	if r.err != nil {
+		r.Unlock()
+		return nil, r.err
+	}
+	if _, ok := r.pending[seq]; ok {
+		r.Unlock()
+		return nil, ErrDuplicateSequence
+	}
+	r.pending[seq] = wait
+	r.Unlock()
+
+	defer func() {
+		r.Lock()
+		if r.pending[seq] == wait {
+			delete(r.pending, seq)
+		}
+		r.Unlock()
+	}()
+
+	if err := r.cli.Send(pkt.Marshal(p)); err != nil {
+		return nil, err
+	}
+	select {
+	case resp := <-wait:
+		return resp, nil
+	case <-r.done:
+		// 读循环退出前可能已经投递了响应
+		select {
+		case resp := <-wait:
+			return resp, nil
+		default:
+		}
+		return nil, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Pending 返回在途的请求数
+func (r *Requester) Pending() int {
+	r.Lock()
+	defer r.Unlock()
+	return len(r.pending)
+}
+
+func (r *Requester) start() {
+	r.once.Do(func() {
+		go r.readloop()
+	})
+}
+
+func (r *Requester) readloop() {
+	log := logger.WithFields(logger.Fields{
+		"module": "requester",
+		"id":     r.cli.ServiceID(),
+	})
+	for {
+		frame, err := r.cli.Read()
+		if err != nil {
+			log.Debug(err)
+			r.fail(err)
+			return
+		}
+		if frame.GetOpCode() != OpBinary {
+			ReleaseFrame(frame)
+			continue
+		}
+		// 解码时复制了需要的数据，之后就可以归还frame
+		packet, err := pkt.Read(bytes.NewBuffer(frame.GetPayload()))
+		ReleaseFrame(frame)
+		if err != nil {
+			log.Warn(err)
+			continue
+		}
+		// BasicPkt(如pong)不参与关联
+		p, ok := packet.(*pkt.LogicPkt)
+		if !ok {
+			continue
+		}
+		if p.Flag == pkt.Flag_Response {
+			r.Lock()
+			wait, ok := r.pending[p.Sequence]
+			if ok {
+				delete(r.pending, p.Sequence)
+			}
+			r.Unlock()
+			if ok {
+				wait <- p
+			} else {
+				log.Debugf("response %d is not in flight, dropped", p.Sequence)
+			}
+			continue
+		}
+		r.Lock()
+		handler := r.push
+		r.Unlock()
+		if handler != nil {
+			handler(p)
+		}
+	}
+}
+
+func (r *Requester) fail(err error) {
+	if err == nil {
+		err = ErrRequesterClosed
+	}
+	r.Lock()
+	r.err = err
+	r.Unlock()
+	close(r.done)
+}
@@ -0,0 +1,137 @@
+package HopeIM
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sjmshsh/HopeIM/wire/pkt"
+	"github.com/stretchr/testify/assert"
+)
+
+type testFrame struct {
+	op      OpCode
+	payload []byte
+}
+
+func (f *testFrame) SetOpCode(op OpCode)       { f.op = op }
+func (f *testFrame) GetOpCode() OpCode         { return f.op }
+func (f *testFrame) SetPayload(payload []byte) { f.payload = payload }
+func (f *testFrame) GetPayload() []byte        { return f.payload }
+
+// testClient 把Send的请求交给onSend处理，Read从frames中读取
+type testClient struct {
+	frames chan Frame
+	onSend func(p *pkt.LogicPkt)
+}
+
+func newTestClient() *testClient {
+	return &testClient{frames: make(chan Frame, 16)}
+}
+
+func (c *testClient) ServiceID() string          { return "test" }
+func (c *testClient) ServiceName() string        { return "test" }
+func (c *testClient) GetMeta() map[string]string { return nil }
+func (c *testClient) Connect(string) error       { return nil }
+func (c *testClient) SetDialer(Dialer)           {}
+func (c *testClient) Close()                     { close(c.frames) }
+func (c *testClient) Send(payload []byte) error {
+	p, err := pkt.MustReadLogicPkt(bytes.NewBuffer(payload))
+	if err != nil {
+		return err
+	}
+	if c.onSend != nil {
+		c.onSend(p)
+	}
+	return nil
+}
+
+func (c *testClient) Read() (Frame, error) {
+	f, ok := <-c.frames
+	if !ok {
+		return nil, errors.New("remote side close the channel")
+	}
+	return f, nil
+}
+
+func (c *testClient) write(p pkt.Packet) {
+	c.frames <- &testFrame{op: OpBinary, payload: pkt.Marshal(p)}
+}
+
+func response(req *pkt.LogicPkt, body string) *pkt.LogicPkt {
+	resp := pkt.NewFrom(&req.Header)
+	resp.Flag = pkt.Flag_Response
+	resp.Body = []byte(body)
+	return resp
+}
+
+func TestRequester_Concurrent(t *testing.T) {
+	cli := newTestClient()
+	var mu sync.Mutex
+	var reqs []*pkt.LogicPkt
+	cli.onSend = func(p *pkt.LogicPkt) {
+		mu.Lock()
+		defer mu.Unlock()
+		reqs = append(reqs, p)
+		if len(reqs) < 3 {
+			return
+		}
+		// 倒序返回响应，中间插入一条推送与一个pong
+		cli.write(pkt.New("chat.user.talk", pkt.WithDest("test")).WriteBody(&pkt.MessagePush{Body: "push"}))
+		cli.write(&pkt.BasicPkt{Code: pkt.CodePong})
+		for i := len(reqs) - 1; i >= 0; i-- {
+			cli.write(response(reqs[i], reqs[i].Command))
+		}
+	}
+	r := NewRequester(cli)
+	pushed := make(chan *pkt.LogicPkt, 1)
+	r.SetPushHandler(func(p *pkt.LogicPkt) {
+		pushed <- p
+	})
+
+	var wg sync.WaitGroup
+	for _, command := range []string{"a", "b", "c"} {
+		wg.Add(1)
+		go func(command string) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			resp, err := r.Request(ctx, pkt.New(command))
+			assert.Nil(t, err)
+			assert.Equal(t, command, string(resp.Body))
+		}(command)
+	}
+	wg.Wait()
+	assert.Equal(t, 0, r.Pending())
+
+	select {
+	case p := <-pushed:
+		var push pkt.MessagePush
+		_ = p.ReadBody(&push)
+		assert.Equal(t, "push", push.Body)
+	case <-time.After(time.Second):
+		t.Fatal("push not received")
+	}
+}
+
+func TestRequester_Timeout(t *testing.T) {
+	cli := newTestClient()
+	r := NewRequester(cli)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+	req := pkt.New("a")
+	_, err := r.Request(ctx, req)
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Equal(t, 0, r.Pending())
+
+	// 超时之后到达的响应被丢弃
+	cli.write(response(req, "late"))
+
+	cli.Close()
+	_, err = r.Request(context.Background(), pkt.New("b"))
+	assert.NotNil(t, err)
+}
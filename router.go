@@ -1,22 +1,35 @@
 package HopeIM
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/sjmshsh/HopeIM/wire/pkt"
+	"strings"
 	"sync"
+	"time"
 )
 
 var ErrSessionLost = errors.New("err:session lost")
 
 type Router struct {
 	handlers *FuncTree
-	pool     sync.Pool
+	// 全局middleware，对所有的指令生效
+	middlewares HandlersChain
+	pool        sync.Pool
+	// Dispatch时单次推送给网关的最大channel数量
+	dispatchBatch int
+	// 指令的默认处理超时，0表示不超时
+	timeout time.Duration
+	// 单独设置了处理超时的指令
+	timeouts map[string]time.Duration
 }
 
 func NewRouter() *Router {
 	r := &Router{
-		handlers: NewTree(),
+		handlers:      NewTree(),
+		dispatchBatch: DefaultDispatchBatchSize,
+		timeouts:      make(map[string]time.Duration),
 	}
 	r.pool.New = func() interface{} {
 		return BuildContext()
@@ -28,6 +41,51 @@ func (s *Router) Handle(commond string, handlers ...HandlerFunc) {
 	s.handlers.Add(commond, handlers...)
 }
 
+// SetDispatchBatchSize 设置Dispatch时单次推送给网关的最大channel数量
+func (s *Router) SetDispatchBatchSize(size int) {
+	if size <= 0 {
+		return
+	}
+	s.dispatchBatch = size
+}
+
+// SetTimeout 设置所有指令的默认处理超时，0表示不超时。
+// 超时之后如果handler还没有返回Resp，将自动返回一个SystemException
+func (s *Router) SetTimeout(timeout time.Duration) {
+	s.timeout = timeout
+}
+
+// SetCommandTimeout 单独设置一个指令的处理超时，优先于SetTimeout
+func (s *Router) SetCommandTimeout(command string, timeout time.Duration) {
+	s.timeouts[command] = timeout
+}
+
+func (s *Router) timeoutOf(command string) time.Duration {
+	if timeout, ok := s.timeouts[command]; ok {
+		return timeout
+	}
+	return s.timeout
+}
+
+// Fallback 注册前缀prefix的fallback handler，prefix下没有注册的指令都交给它处理
+func (s *Router) Fallback(prefix string, handlers ...HandlerFunc) {
+	s.handlers.AddFallback(prefix, handlers...)
+}
+
+// Use 添加全局middleware，包括未注册的指令在内，所有的请求都会先经过它们
+func (s *Router) Use(middlewares ...HandlerFunc) {
+	s.middlewares = append(s.middlewares, middlewares...)
+}
+
+// Group 创建一个指令分组，比如Group("chat.group")下注册的create对应chat.group.create
+func (s *Router) Group(prefix string, middlewares ...HandlerFunc) *RouterGroup {
+	return &RouterGroup{
+		prefix:      prefix,
+		middlewares: middlewares,
+		router:      s,
+	}
+}
+
 func (s *Router) Serve(packet *pkt.LogicPkt, dispather Dispather, cache SessionStorage, session Session) error {
 	if dispather == nil {
 		return fmt.Errorf("dispather is nil")
@@ -41,21 +99,48 @@ func (s *Router) Serve(packet *pkt.LogicPkt, dispather Dispather, cache SessionS
 	ctx.Dispather = dispather
 	ctx.SessionStorage = cache
 	ctx.session = session
+	ctx.dispatchBatch = s.dispatchBatch
+
+	timeout := s.timeoutOf(packet.Command)
+	if timeout <= 0 {
+		s.serveContext(ctx)
+		s.pool.Put(ctx)
+		return nil
+	}
+
+	var cancel context.CancelFunc
+	ctx.ctx, cancel = context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	// 提前初始化session，避免与handler所在的goroutine竞争
+	_ = ctx.Session()
 
-	s.serveContext(ctx)
-	s.pool.Put(ctx)
+	done := make(chan struct{})
+	go func() {
+		s.serveContext(ctx)
+		close(done)
+	}()
+	select {
+	case <-done:
+		s.pool.Put(ctx)
+	case <-ctx.ctx.Done():
+		ctx.timeout()
+		// handler仍然持有ctx，等它返回之后才能放回pool中
+		go func() {
+			<-done
+			s.pool.Put(ctx)
+		}()
+	}
 	return nil
 }
 
 func (s *Router) serveContext(ctx *ContextImpl) {
 	chain, ok := s.handlers.Get(ctx.Header().Command)
 	if !ok {
-		ctx.handlers = []HandlerFunc{handleNoFound}
-		ctx.Next()
-		return
+		chain = HandlersChain{handleNoFound}
 	}
-
-	ctx.handlers = chain
+	// ctx.handlers是Context自己持有的buffer，不能直接引用chain，否则reset之后会改写路由表
+	ctx.handlers = append(ctx.handlers, s.middlewares...)
+	ctx.handlers = append(ctx.handlers, chain...)
 	ctx.Next()
 }
 
@@ -63,21 +148,175 @@ func handleNoFound(ctx Context) {
 	_ = ctx.Resp(pkt.Status_NotImplemented, &pkt.ErrorResp{Message: "NotImplemented"})
 }
 
+// RouterGroup 指令分组，分组下的指令共享分组的middleware
+type RouterGroup struct {
+	prefix      string
+	middlewares HandlersChain
+	router      *Router
+}
+
+// Use 添加分组的middleware，只对之后注册的指令生效
+func (g *RouterGroup) Use(middlewares ...HandlerFunc) {
+	g.middlewares = append(g.middlewares, middlewares...)
+}
+
+// Handle 注册分组下的指令，command为空时注册分组本身
+func (g *RouterGroup) Handle(command string, handlers ...HandlerFunc) {
+	g.router.Handle(g.fullCommand(command), g.combine(handlers)...)
+}
+
+// Fallback 注册分组的fallback handler，分组下没有注册的指令都交给它处理
+func (g *RouterGroup) Fallback(handlers ...HandlerFunc) {
+	g.router.Fallback(g.prefix, g.combine(handlers)...)
+}
+
+// Group 创建一个子分组，子分组继承当前分组的middleware
+func (g *RouterGroup) Group(prefix string, middlewares ...HandlerFunc) *RouterGroup {
+	return &RouterGroup{
+		prefix:      g.fullCommand(prefix),
+		middlewares: g.combine(middlewares),
+		router:      g.router,
+	}
+}
+
+func (g *RouterGroup) fullCommand(command string) string {
+	if command == "" {
+		return g.prefix
+	}
+	if g.prefix == "" {
+		return command
+	}
+	return g.prefix + "." + command
+}
+
+func (g *RouterGroup) combine(handlers HandlersChain) HandlersChain {
+	merged := make(HandlersChain, 0, len(g.middlewares)+len(handlers))
+	merged = append(merged, g.middlewares...)
+	return append(merged, handlers...)
+}
+
+const (
+	// WildcardOne 匹配指令中的一段，比如chat.*.talk
+	WildcardOne = "*"
+	// WildcardAll 匹配指令中剩余的所有段（包括零段），只能出现在最后，比如chat.group.**
+	WildcardAll = "**"
+)
+
+// FuncTree 以"."分隔的指令为路径的前缀树，逐段匹配，每一段的优先级为 精确匹配 > * > **，
+// 都没有匹配时使用路径上最深的一个fallback
 type FuncTree struct {
-	nodes map[string]HandlersChain
+	root *funcNode
+}
+
+type funcNode struct {
+	children map[string]*funcNode
+	handlers HandlersChain
+	leaf     bool
+	fallback HandlersChain
 }
 
 func NewTree() *FuncTree {
 	return &FuncTree{
-		nodes: make(map[string]HandlersChain, 10),
+		root: newFuncNode(),
+	}
+}
+
+func newFuncNode() *funcNode {
+	return &funcNode{
+		children: make(map[string]*funcNode),
 	}
 }
 
+// Add 注册一个指令，指令中可以使用*和**
 func (t *FuncTree) Add(path string, handlers ...HandlerFunc) {
-	t.nodes[path] = append(t.nodes[path], handlers...)
+	if i := strings.Index(path, WildcardAll); i >= 0 && i != len(path)-len(WildcardAll) {
+		panic(fmt.Sprintf("wildcard %s must be the last segment of command %s", WildcardAll, path))
+	}
+	n := t.node(path)
+	n.handlers = append(n.handlers, handlers...)
+	n.leaf = true
+}
+
+// AddFallback 为前缀prefix注册fallback，prefix下没有匹配到的指令都交给它处理，prefix为空时对所有指令生效
+func (t *FuncTree) AddFallback(prefix string, handlers ...HandlerFunc) {
+	n := t.node(prefix)
+	n.fallback = append(n.fallback, handlers...)
+}
+
+func (t *FuncTree) node(path string) *funcNode {
+	n := t.root
+	if path == "" {
+		return n
+	}
+	for path != "" {
+		var seg string
+		seg, path = nextSegment(path)
+		child, ok := n.children[seg]
+		if !ok {
+			child = newFuncNode()
+			n.children[seg] = child
+		}
+		n = child
+	}
+	return n
 }
 
 func (t *FuncTree) Get(path string) (HandlersChain, bool) {
-	f, ok := t.nodes[path]
-	return f, ok
+	if chain, ok := t.root.match(path); ok {
+		return chain, true
+	}
+	// 查找路径上最深的fallback
+	var fallback HandlersChain
+	n := t.root
+	for {
+		if n.fallback != nil {
+			fallback = n.fallback
+		}
+		if path == "" {
+			break
+		}
+		var seg string
+		seg, path = nextSegment(path)
+		child, ok := n.children[seg]
+		if !ok {
+			break
+		}
+		n = child
+	}
+	return fallback, fallback != nil
+}
+
+func (n *funcNode) match(path string) (HandlersChain, bool) {
+	if path == "" {
+		if n.leaf {
+			return n.handlers, true
+		}
+		if all, ok := n.children[WildcardAll]; ok && all.leaf {
+			return all.handlers, true
+		}
+		return nil, false
+	}
+	seg, rest := nextSegment(path)
+	if child, ok := n.children[seg]; ok {
+		if chain, ok := child.match(rest); ok {
+			return chain, true
+		}
+	}
+	if child, ok := n.children[WildcardOne]; ok {
+		if chain, ok := child.match(rest); ok {
+			return chain, true
+		}
+	}
+	if all, ok := n.children[WildcardAll]; ok && all.leaf {
+		return all.handlers, true
+	}
+	return nil, false
+}
+
+func nextSegment(path string) (string, string) {
+	i := strings.IndexByte(path, '.')
+	if i < 0 {
+		return path, ""
+	}
+	return path[:i], path[i+1:]
 }
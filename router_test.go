@@ -0,0 +1,210 @@
+package HopeIM
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sjmshsh/HopeIM/wire/pkt"
+	"github.com/stretchr/testify/assert"
+)
+
+type testDispather struct {
+	sync.Mutex
+	packets []*pkt.LogicPkt
+}
+
+func (d *testDispather) Push(ctx context.Context, gateway string, channels []string, p *pkt.LogicPkt) error {
+	d.Lock()
+	defer d.Unlock()
+	d.packets = append(d.packets, p)
+	return nil
+}
+
+type testStorage struct{}
+
+func (s *testStorage) Add(session *pkt.Session) error                      { return nil }
+func (s *testStorage) Delete(account string, channelId string) error       { return nil }
+func (s *testStorage) Get(channelId string) (*pkt.Session, error)          { return nil, ErrSessionNil }
+func (s *testStorage) GetLocations(account ...string) ([]*Location, error) { return nil, ErrSessionNil }
+func (s *testStorage) GetLocation(account string, device string) (*Location, error) {
+	return nil, ErrSessionNil
+}
+func (s *testStorage) Touch(gateId string, sessions ...*pkt.TouchSession) ([]*pkt.TouchSession, error) {
+	return nil, nil
+}
+
+var testSession = &pkt.Session{ChannelId: "ch1", GateId: "gate1", Account: "test1"}
+
+func serve(r *Router, command string) *testDispather {
+	d := &testDispather{}
+	_ = r.Serve(pkt.New(command), d, &testStorage{}, testSession)
+	return d
+}
+
+func record(trace *[]string, name string) HandlerFunc {
+	return func(ctx Context) {
+		*trace = append(*trace, name)
+	}
+}
+
+func TestRouter_Middleware(t *testing.T) {
+	var trace []string
+	r := NewRouter()
+	r.Use(func(ctx Context) {
+		trace = append(trace, "before")
+		ctx.Next()
+		trace = append(trace, "after")
+	})
+	r.Handle("chat.user.talk", record(&trace, "h1"), record(&trace, "h2"))
+
+	serve(r, "chat.user.talk")
+	assert.Equal(t, []string{"before", "h1", "h2", "after"}, trace)
+
+	// global middleware wraps unknown commands too
+	trace = nil
+	d := serve(r, "chat.user.unknown")
+	assert.Equal(t, []string{"before", "after"}, trace)
+	assert.Equal(t, pkt.Status_NotImplemented, d.packets[0].Status)
+}
+
+func TestRouter_Group(t *testing.T) {
+	var trace []string
+	r := NewRouter()
+	g := r.Group("chat.group", record(&trace, "group"))
+	g.Handle("create", record(&trace, "create"))
+	sub := g.Group("member", record(&trace, "member"))
+	sub.Handle("join", record(&trace, "join"))
+
+	serve(r, "chat.group.create")
+	assert.Equal(t, []string{"group", "create"}, trace)
+
+	trace = nil
+	serve(r, "chat.group.member.join")
+	assert.Equal(t, []string{"group", "member", "join"}, trace)
+}
+
+func TestRouter_Abort(t *testing.T) {
+	var trace []string
+	r := NewRouter()
+	r.Use(func(ctx Context) {
+		_ = ctx.Resp(pkt.Status_Unauthorized, nil)
+		ctx.Abort()
+		assert.True(t, ctx.IsAborted())
+	})
+	r.Handle("chat.user.talk", record(&trace, "h1"))
+
+	d := serve(r, "chat.user.talk")
+	assert.Empty(t, trace)
+	assert.Equal(t, 1, len(d.packets))
+	assert.Equal(t, pkt.Status_Unauthorized, d.packets[0].Status)
+
+	// the pooled context must not leak the aborted state
+	r2 := NewRouter()
+	r2.Handle("chat.user.talk", record(&trace, "h1"))
+	serve(r2, "chat.user.talk")
+	serve(r2, "chat.user.talk")
+	assert.Equal(t, []string{"h1", "h1"}, trace)
+}
+
+func TestFuncTree_Wildcard(t *testing.T) {
+	var trace []string
+	r := NewRouter()
+	r.Handle("chat.group.create", record(&trace, "create"))
+	r.Handle("chat.*.talk", record(&trace, "talk"))
+	r.Handle("chat.group.**", record(&trace, "group"))
+	r.Handle("plugin.**", record(&trace, "plugin"))
+
+	for _, tc := range []struct {
+		command string
+		want    string
+	}{
+		{"chat.group.create", "create"},
+		{"chat.user.talk", "talk"},
+		// matched segment by segment, the exact segment group wins over *
+		{"chat.group.talk", "group"},
+		{"chat.group.join", "group"},
+		{"chat.group.member.join", "group"},
+		{"plugin", "plugin"},
+		{"plugin.a.b.c", "plugin"},
+	} {
+		trace = nil
+		serve(r, tc.command)
+		assert.Equal(t, []string{tc.want}, trace, tc.command)
+	}
+
+	assert.Panics(t, func() { r.Handle("chat.**.talk") })
+}
+
+func TestFuncTree_Fallback(t *testing.T) {
+	var trace []string
+	r := NewRouter()
+	r.Handle("chat.user.talk", record(&trace, "talk"))
+	r.Fallback("chat", record(&trace, "chat"))
+	r.Group("chat.group").Fallback(record(&trace, "group"))
+
+	for _, tc := range []struct {
+		command string
+		want    []string
+	}{
+		{"chat.user.talk", []string{"talk"}},
+		{"chat.user.unknown", []string{"chat"}},
+		{"chat.group.unknown", []string{"group"}},
+		{"chat.group.a.b", []string{"group"}},
+		{"login.signin", nil},
+	} {
+		trace = nil
+		serve(r, tc.command)
+		assert.Equal(t, tc.want, trace, tc.command)
+	}
+}
+
+func TestRouter_Timeout(t *testing.T) {
+	r := NewRouter()
+	r.SetTimeout(time.Second)
+	r.SetCommandTimeout("chat.user.talk", time.Millisecond*20)
+
+	released := make(chan error)
+	r.Handle("chat.user.talk", func(ctx Context) {
+		_, ok := ctx.Deadline()
+		assert.True(t, ok)
+		// a slow downstream call which honors the context
+		<-ctx.Done()
+		time.Sleep(time.Millisecond * 10)
+		released <- ctx.Resp(pkt.Status_Success, nil)
+	})
+	d := serve(r, "chat.user.talk")
+	assert.Equal(t, context.DeadlineExceeded, <-released)
+	d.Lock()
+	defer d.Unlock()
+	assert.Equal(t, 1, len(d.packets))
+	assert.Equal(t, pkt.Status_SystemException, d.packets[0].Status)
+
+	r.Handle("chat.user.fast", func(ctx Context) {
+		_ = ctx.Resp(pkt.Status_Success, nil)
+	})
+	d = serve(r, "chat.user.fast")
+	assert.Equal(t, 1, len(d.packets))
+	assert.Equal(t, pkt.Status_Success, d.packets[0].Status)
+}
+
+func TestRouter_RespAfterDeadline(t *testing.T) {
+	r := NewRouter()
+	r.SetTimeout(time.Millisecond * 20)
+
+	released := make(chan error)
+	r.Handle("chat.user.talk", func(ctx Context) {
+		// ctx结束之后立即返回，即使timeout还没有执行也不能再Resp
+		<-ctx.Done()
+		released <- ctx.Resp(pkt.Status_Success, nil)
+	})
+	for i := 0; i < 10; i++ {
+		d := serve(r, "chat.user.talk")
+		assert.Equal(t, context.DeadlineExceeded, <-released)
+		d.Lock()
+		assert.Equal(t, 1, len(d.packets))
+		assert.Equal(t, pkt.Status_SystemException, d.packets[0].Status)
+		d.Unlock()
+	}
+}
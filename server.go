@@ -2,6 +2,7 @@ package HopeIM
 
 import (
 	"context"
+	"crypto/tls"
 	"net"
 	"time"
 )
@@ -11,6 +12,19 @@ const (
 	DefaultWriteWait = time.Second * 10
 	DefaultLoginWait = time.Second * 10
 	DefaultHeartbeat = time.Second * 55
+
+	DefaultWriteQueueSize    = 64
+	DefaultWriteQueueTimeout = time.Second
+
+	DefaultReadWorkers   = 64
+	DefaultReadQueueSize = 128
+
+	DefaultChannelShards = 64
+
+	DefaultDrainBatchSize = 1000
+	DefaultDrainInterval  = time.Millisecond * 100
+
+	DefaultRateLimitIdle = time.Minute * 10
 )
 
 // 定义了基础服务的抽象接口
@@ -60,26 +74,29 @@ type Server interface {
 // Acceptor 连接接收器
 type Acceptor interface {
 	// Accept 返回一个握手完成的Channel对象或者一个error。
-	// 业务层需要处理不同协议和网络环境的下连接握手协议
-	Accept(Conn, time.Duration) (string, error)
+	// 业务层需要处理不同协议和网络环境的下连接握手协议，
+	// 同时可以返回连接的属性，如account、app等，返回nil时使用空的属性
+	Accept(Conn, time.Duration) (string, *Attributes, error)
 }
 
 // MessageListener 监听消息
 type MessageListener interface {
-	// 收到消息回调
+	// 收到消息回调，payload可能来自缓冲池，只在Receive返回之前有效
 	Receive(Agent, []byte)
 }
 
 // StateListener 状态监听器
 type StateListener interface {
-	// 连接断开回调
-	Disconnect(string) error
+	// 连接断开回调，此时Agent已经从ChannelMap中移除，只能读取它的ID与属性
+	Disconnect(Agent) error
 }
 
 // Agent is interface of client side
 type Agent interface {
 	ID() string
 	Push([]byte) error
+	// Attributes 连接的属性
+	Attributes() *Attributes
 }
 
 // Conn Connection
@@ -100,6 +117,12 @@ type Channel interface {
 	// SetWriteWait 设置写超时
 	SetWriteWait(time.Duration)
 	SetReadWait(time.Duration)
+	// QueueStats 发送队列的计数器
+	QueueStats() QueueStats
+	// SetReadDispatcher 设置Readloop中消息的分发方式
+	SetReadDispatcher(ReadDispatcher)
+	// SetAttributes 设置连接的属性，需要在Readloop之前调用
+	SetAttributes(*Attributes)
 }
 
 // Client is interface of client side
@@ -125,6 +148,10 @@ type DialerContext struct {
 	Name    string
 	Address string
 	Timeout time.Duration
+	// TLSConfig 不为nil时使用TLS拨号
+	TLSConfig *tls.Config
+	// Compression 是否请求压缩，目前只有websocket支持permessage-deflate
+	Compression bool
 }
 
 // OpCode OpCode
@@ -147,3 +174,15 @@ type Frame interface {
 	SetPayload([]byte)
 	GetPayload() []byte
 }
+
+// frameReleaser payload使用池化缓冲区的Frame
+type frameReleaser interface {
+	Release()
+}
+
+// ReleaseFrame 把Frame的payload归还到缓冲池，之后不能再访问payload；不使用缓冲池的Frame不做任何处理
+func ReleaseFrame(frame Frame) {
+	if r, ok := frame.(frameReleaser); ok {
+		r.Release()
+	}
+}
@@ -3,6 +3,7 @@ package conf
 import (
 	"fmt"
 	"github.com/sjmshsh/HopeIM/logger"
+	"time"
 
 	"github.com/kelseyhightower/envconfig"
 	"github.com/spf13/viper"
@@ -18,6 +19,41 @@ type Config struct {
 	PublicPort    int      `envconfig:"publicPort"`
 	Tags          []string `envconfig:"tags"`
 	ConsulURL     string   `envconfig:"consulURL"`
+	// 每个Channel的发送队列
+	WriteQueueSize    int           `envconfig:"writeQueueSize"`
+	WriteQueuePolicy  string        `envconfig:"writeQueuePolicy"` // block,drop_oldest,drop_newest,disconnect
+	WriteQueueTimeout time.Duration `envconfig:"writeQueueTimeout"`
+	// 上行消息的处理协程池，ReadWorkers为0时每条消息启动一个goroutine
+	ReadWorkers   int `envconfig:"readWorkers"`
+	ReadQueueSize int `envconfig:"readQueueSize"`
+	// 下线时排空连接：每批通知并关闭DrainBatchSize个连接，间隔DrainInterval，超过DrainTimeout之后关闭所有连接
+	DrainTimeout   time.Duration `envconfig:"drainTimeout"`
+	DrainBatchSize int           `envconfig:"drainBatchSize"`
+	DrainInterval  time.Duration `envconfig:"drainInterval"`
+	// 定时向逻辑服务上报在线的channel，刷新会话的过期时间，为0时使用默认值
+	SessionTouchInterval time.Duration `envconfig:"sessionTouchInterval"`
+	// 连接准入控制，0表示不限制
+	MaxConnections      int      `envconfig:"maxConnections"`
+	MaxConnectionsPerIP int      `envconfig:"maxConnectionsPerIP"`
+	AcceptRate          float64  `envconfig:"acceptRate"` // 每秒接收的新连接数
+	AcceptBurst         int      `envconfig:"acceptBurst"`
+	AllowCIDRs          []string `envconfig:"allowCIDRs"`
+	DenyCIDRs           []string `envconfig:"denyCIDRs"`
+	// TLS 配置了证书时使用wss，证书文件变化之后自动重新加载
+	TLSCertFile          string        `envconfig:"tlsCertFile"`
+	TLSKeyFile           string        `envconfig:"tlsKeyFile"`
+	TLSClientCAFile      string        `envconfig:"tlsClientCAFile"`
+	TLSRequireClientCert bool          `envconfig:"tlsRequireClientCert"`
+	TLSReloadInterval    time.Duration `envconfig:"tlsReloadInterval"`
+	// websocket permessage-deflate，负载长度达到CompressionThreshold的消息才压缩
+	Compression          bool `envconfig:"compression"`
+	CompressionThreshold int  `envconfig:"compressionThreshold"`
+	CompressionLevel     int  `envconfig:"compressionLevel"`
+	// 客户端消息的大小限制，为0时使用wire中的默认值
+	MaxFrameSize  int `envconfig:"maxFrameSize"`
+	MaxHeaderSize int `envconfig:"maxHeaderSize"`
+	MaxBodySize   int `envconfig:"maxBodySize"`
+	MaxMetaCount  int `envconfig:"maxMetaCount"`
 }
 
 // Init InitConfig
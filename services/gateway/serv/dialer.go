@@ -21,7 +21,7 @@ func NewDialer(serviceId string) HopeIM.Dialer {
 
 func (d *TcpDialer) DialAndHandshake(ctx HopeIM.DialerContext) (net.Conn, error) {
 	// 1. 拨号建立连接
-	conn, err := net.DialTimeout("tcp", ctx.Address, ctx.Timeout)
+	conn, err := tcp.Dial(ctx.Address, ctx.Timeout, ctx.TLSConfig)
 	if err != nil {
 		return nil, err
 	}
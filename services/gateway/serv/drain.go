@@ -0,0 +1,47 @@
+package serv
+
+import (
+	"sync"
+
+	"github.com/sjmshsh/HopeIM"
+	"github.com/sjmshsh/HopeIM/container"
+	"github.com/sjmshsh/HopeIM/naming"
+	"github.com/sjmshsh/HopeIM/wire"
+	"github.com/sjmshsh/HopeIM/wire/pkt"
+)
+
+// NewReconnectNotifier 生成网关下线时发送给客户端的重连通知，
+// 从naming中找到同名的其它网关，按照channelId选择一个作为建议的重连地址
+func NewReconnectNotifier(serviceID, serviceName string, ns naming.Naming) func(ch HopeIM.Channel) []byte {
+	var (
+		once  sync.Once
+		peers []string
+	)
+	return func(ch HopeIM.Channel) []byte {
+		once.Do(func() {
+			services, err := ns.Find(serviceName)
+			if err != nil {
+				log.Warn(err)
+				return
+			}
+			for _, service := range services {
+				if service.ServiceID() == serviceID {
+					continue
+				}
+				peers = append(peers, service.DialURL())
+			}
+			log.Infof("reconnect peers of %s: %v", serviceID, peers)
+		})
+		notify := &pkt.ReconnectNotify{
+			Reason: "gateway shutdown",
+		}
+		if len(peers) > 0 {
+			notify.Address = peers[container.HashCode(ch.ID())%len(peers)]
+		}
+		p := pkt.New(wire.CommandGatewayReconnect, pkt.WithChannel(ch.ID()),
+			pkt.WithContentType(ch.Attributes().ContentType))
+		p.Flag = pkt.Flag_Push
+		p.WriteBody(notify)
+		return pkt.Marshal(p)
+	}
+}
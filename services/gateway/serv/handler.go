@@ -10,6 +10,8 @@ import (
 	"github.com/sjmshsh/HopeIM/wire/pkt"
 	"github.com/sjmshsh/HopeIM/wire/token"
 	"regexp"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -20,9 +22,11 @@ var log = logger.WithFields(logger.Fields{
 
 type Handler struct {
 	ServiceID string
+	// Limits 客户端消息的大小限制，超过限制时关闭连接
+	Limits wire.Limits
 }
 
-func (h *Handler) Accept(conn HopeIM.Conn, timeout time.Duration) (string, error) {
+func (h *Handler) Accept(conn HopeIM.Conn, timeout time.Duration) (string, *HopeIM.Attributes, error) {
 	log := logger.WithFields(logger.Fields{
 		"ServiceID": h.ServiceID,
 		"module":    "Handler",
@@ -33,27 +37,33 @@ func (h *Handler) Accept(conn HopeIM.Conn, timeout time.Duration) (string, error
 	_ = conn.SetReadDeadline(time.Now().Add(timeout))
 	frame, err := conn.ReadFrame()
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 
 	buf := bytes.NewBuffer(frame.GetPayload())
-	req, err := pkt.MustReadLogicPkt(buf)
+	packet, err := pkt.ReadWithLimits(buf, h.Limits)
+	HopeIM.ReleaseFrame(frame)
 	if err != nil {
-		return "", err
+		HopeIM.CountLimitExceeded(err)
+		return "", nil, err
+	}
+	req, ok := packet.(*pkt.LogicPkt)
+	if !ok {
+		return "", nil, fmt.Errorf("packet is not a logic packet")
 	}
 	// 2. 必须是登录包
 	if req.Command != wire.CommandLoginSignIn {
 		resp := pkt.NewFrom(&req.Header)
 		resp.Status = pkt.Status_InvalidCommand
 		_ = conn.WriteFrame(HopeIM.OpBinary, pkt.Marshal(resp))
-		return "", fmt.Errorf("must be a InvalidCommand command")
+		return "", nil, fmt.Errorf("must be a InvalidCommand command")
 	}
 
 	// 3. 反序列化Body
 	var login pkt.LoginReq
 	err = req.ReadBody(&login)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 	// 4. 使用默认的DefaultSecret解析token
 	tk, err := token.Parse(token.DefaultSecret, login.Token)
@@ -62,32 +72,51 @@ func (h *Handler) Accept(conn HopeIM.Conn, timeout time.Duration) (string, error
 		resp := pkt.NewFrom(&req.Header)
 		resp.Status = pkt.Status_Unauthorized
 		_ = conn.WriteFrame(HopeIM.OpBinary, pkt.Marshal(resp))
-		return "", err
+		return "", nil, err
 	}
 	// 6. 生成一个全局唯一的ChannelID
 	id := generateChannelID(h.ServiceID, tk.Account)
 
+	attrs := HopeIM.NewAttributes()
+	attrs.Account = tk.Account
+	attrs.App = tk.App
+	attrs.Device = login.Device
+	attrs.RemoteIP = getIP(conn.RemoteAddr().String())
+	attrs.LoginTime = time.Now()
+	// 登录包的ContentType就是这个连接协商的消息体编码
+	attrs.ContentType = req.ContentType
+
 	req.ChannelId = id
 	req.WriteBody(&pkt.Session{
-		ChannelId: id,
-		GateId:    h.ServiceID,
-		Account:   tk.Account,
-		RemoteIP:  getIP(conn.RemoteAddr().String()),
-		App:       tk.App,
+		ChannelId:   id,
+		GateId:      h.ServiceID,
+		Account:     attrs.Account,
+		RemoteIP:    attrs.RemoteIP,
+		App:         attrs.App,
+		Device:      attrs.Device,
+		Zone:        login.Zone,
+		Isp:         login.Isp,
+		ContentType: attrs.ContentType,
 	})
 	// 7. 把login转发给Login服务
 	err = container.Forward(wire.SNLogin, req)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
-	return id, nil
+	return id, attrs, nil
 }
 
 func (h *Handler) Receive(ag HopeIM.Agent, payload []byte) {
 	buf := bytes.NewBuffer(payload)
-	packet, err := pkt.Read(buf)
+	packet, err := pkt.ReadWithLimits(buf, h.Limits)
 	if err != nil {
 		log.Error(err)
+		// 超过大小限制的客户端直接断开
+		if HopeIM.CountLimitExceeded(err) {
+			if ch, ok := ag.(HopeIM.Channel); ok {
+				_ = ch.Close()
+			}
+		}
 		return
 	}
 	if basicPkt, ok := packet.(*pkt.BasicPkt); ok {
@@ -97,29 +126,41 @@ func (h *Handler) Receive(ag HopeIM.Agent, payload []byte) {
 		return
 	}
 	if logicPkt, ok := packet.(*pkt.LogicPkt); ok {
+		// 登录、登出与会话续期只能由网关发出
+		if strings.HasPrefix(logicPkt.Command, "login.") {
+			log.Warnf("drop %s from client %s", logicPkt.Command, ag.ID())
+			return
+		}
+		logicPkt.DelMeta(wire.MetaFromGateway)
+		logicPkt.DelMeta(wire.MetaRecvTime)
+		logicPkt.AddStringMeta(wire.MetaRecvTime, strconv.FormatInt(time.Now().UnixNano(), 10))
 		logicPkt.ChannelId = ag.ID()
 
 		err = container.Forward(logicPkt.ServiceName(), logicPkt)
 		if err != nil {
 			logger.WithFields(logger.Fields{
-				"module": "handler",
-				"id":     ag.ID(),
-				"cmd":    logicPkt.Command,
-				"dest":   logicPkt.Dest,
+				"module":  "handler",
+				"id":      ag.ID(),
+				"account": ag.Attributes().Account,
+				"cmd":     logicPkt.Command,
+				"dest":    logicPkt.Dest,
 			}).Error(err)
 		}
 	}
 }
 
-func (h *Handler) Disconnect(id string) error {
-	log.Infof("disconnect %s", id)
+func (h *Handler) Disconnect(ag HopeIM.Agent) error {
+	id := ag.ID()
+	attrs := ag.Attributes()
+	log.Infof("disconnect %s of %s, online %v", id, attrs.Account, time.Since(attrs.LoginTime))
 
 	logout := pkt.New(wire.CommandLoginSignOut, pkt.WithChannel(id))
 	err := container.Forward(wire.SNLogin, logout)
 	if err != nil {
 		logger.WithFields(logger.Fields{
-			"module": "handler",
-			"id":     id,
+			"module":  "handler",
+			"id":      id,
+			"account": attrs.Account,
 		}).Error(err)
 	}
 	return nil
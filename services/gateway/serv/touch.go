@@ -0,0 +1,68 @@
+package serv
+
+import (
+	"context"
+	"time"
+
+	"github.com/sjmshsh/HopeIM"
+	"github.com/sjmshsh/HopeIM/container"
+	"github.com/sjmshsh/HopeIM/wire"
+	"github.com/sjmshsh/HopeIM/wire/pkt"
+)
+
+const (
+	DefaultTouchInterval  = time.Minute * 10
+	DefaultTouchBatchSize = 1000
+)
+
+// TouchLoop 定时把网关上仍然在线的channel批量上报给逻辑服务，刷新会话与Location的过期时间。
+// 长时间在线但是没有重新登录的连接不会因为过期而收不到消息
+func TouchLoop(ctx context.Context, serviceID string, channels HopeIM.ChannelMap, interval time.Duration, batch int) {
+	if interval <= 0 {
+		interval = DefaultTouchInterval
+	}
+	if batch <= 0 {
+		batch = DefaultTouchBatchSize
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		n := touchChannels(serviceID, channels, batch)
+		log.Debugf("touch %d sessions", n)
+	}
+}
+
+func touchChannels(serviceID string, channels HopeIM.ChannelMap, batch int) int {
+	// Range持有分片的读锁，先收集再发送，避免在锁内写入逻辑服务
+	var sessions []*pkt.TouchSession
+	channels.Range(func(ch HopeIM.Channel) bool {
+		if account := ch.Attributes().Account; account != "" {
+			sessions = append(sessions, &pkt.TouchSession{
+				ChannelId: ch.ID(),
+				Account:   account,
+			})
+		}
+		return true
+	})
+	for i := 0; i < len(sessions); i += batch {
+		end := i + batch
+		if end > len(sessions) {
+			end = len(sessions)
+		}
+		req := &pkt.SessionTouchReq{Sessions: sessions[i:end]}
+		// Forward要求packet中有channelId，使用批次中的第一个channel选择逻辑服务
+		p := pkt.New(wire.CommandLoginTouch, pkt.WithChannel(req.Sessions[0].ChannelId),
+			pkt.WithContentType(pkt.ContentType_Protobuf))
+		p.WriteBody(req)
+		p.AddStringMeta(wire.MetaFromGateway, serviceID)
+		if err := container.Forward(wire.SNLogin, p); err != nil {
+			log.Warnf("touch %d sessions failed: %v", len(req.Sessions), err)
+		}
+	}
+	return len(sessions)
+}
@@ -2,6 +2,7 @@ package gateway
 
 import (
 	"context"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/sjmshsh/HopeIM"
 	"github.com/sjmshsh/HopeIM/container"
 	"github.com/sjmshsh/HopeIM/logger"
@@ -44,8 +45,22 @@ func RunServerStart(ctx context.Context, opts *ServerStartOptions, version strin
 		Level: "trace",
 	})
 
+	limits := wire.DefaultLimits()
+	if config.MaxFrameSize > 0 {
+		limits.MaxFrameSize = config.MaxFrameSize
+	}
+	if config.MaxHeaderSize > 0 {
+		limits.MaxHeaderSize = config.MaxHeaderSize
+	}
+	if config.MaxBodySize > 0 {
+		limits.MaxBodySize = config.MaxBodySize
+	}
+	if config.MaxMetaCount > 0 {
+		limits.MaxMetaCount = config.MaxMetaCount
+	}
 	handler := &serv.Handler{
 		ServiceID: config.ServiceID,
+		Limits:    limits,
 	}
 
 	var srv HopeIM.Server
@@ -57,25 +72,104 @@ func RunServerStart(ctx context.Context, opts *ServerStartOptions, version strin
 		Protocol: opts.protocol,
 		Tags:     config.Tags,
 	}
+	policy, err := HopeIM.ParseOverflowPolicy(config.WriteQueuePolicy)
+	if err != nil {
+		return err
+	}
+	queue := HopeIM.WriteQueueOptions{
+		Size:    config.WriteQueueSize,
+		Policy:  policy,
+		Timeout: config.WriteQueueTimeout,
+	}
+	var reader HopeIM.ReadDispatcher = HopeIM.GoroutineDispatcher{}
+	if config.ReadWorkers > 0 {
+		reader = HopeIM.NewPoolDispatcher(config.ReadWorkers, config.ReadQueueSize)
+	}
+	ns, err := consul.NewNaming(config.ConsulURL)
+	if err != nil {
+		return err
+	}
+	drain := HopeIM.DefaultDrainOptions()
+	if config.DrainBatchSize > 0 {
+		drain.BatchSize = config.DrainBatchSize
+	}
+	if config.DrainInterval > 0 {
+		drain.Interval = config.DrainInterval
+	}
+	drain.Notify = serv.NewReconnectNotifier(config.ServiceID, config.ServiceName, ns)
+
+	admission, err := HopeIM.NewAdmission(HopeIM.AdmissionOptions{
+		MaxConnections:      config.MaxConnections,
+		MaxConnectionsPerIP: config.MaxConnectionsPerIP,
+		AcceptRate:          config.AcceptRate,
+		AcceptBurst:         config.AcceptBurst,
+		Allow:               config.AllowCIDRs,
+		Deny:                config.DenyCIDRs,
+	})
+	if err != nil {
+		return err
+	}
+
 	if opts.protocol == "ws" {
-		srv = websocket.NewServer(config.Listen, service)
+		options := []websocket.ServerOption{
+			websocket.WithWriteQueue(queue),
+			websocket.WithReadDispatcher(reader),
+			websocket.WithDrain(drain),
+			websocket.WithAdmission(admission),
+			websocket.WithMaxFrameSize(limits.MaxFrameSize),
+		}
+		if config.Compression {
+			compression := websocket.DefaultCompressionOptions()
+			if config.CompressionThreshold > 0 {
+				compression.Threshold = config.CompressionThreshold
+			}
+			if config.CompressionLevel != 0 {
+				compression.Level = config.CompressionLevel
+			}
+			options = append(options, websocket.WithCompression(compression))
+		}
+		if config.TLSCertFile != "" {
+			tlsConfig, err := HopeIM.NewServerTLSConfig(HopeIM.TLSOptions{
+				CertFile:          config.TLSCertFile,
+				KeyFile:           config.TLSKeyFile,
+				ClientCAFile:      config.TLSClientCAFile,
+				RequireClientCert: config.TLSRequireClientCert,
+				ReloadInterval:    config.TLSReloadInterval,
+			})
+			if err != nil {
+				return err
+			}
+			options = append(options, websocket.WithTLS(tlsConfig))
+			// 注册到naming中的地址使用wss
+			service.Protocol = "wss"
+		}
+		srv = websocket.NewServer(config.Listen, service, options...)
 	}
 
+	channels := HopeIM.NewChannels(HopeIM.DefaultChannelShards)
+	srv.SetChannelMap(channels)
+	prometheus.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: "hopeim",
+		Subsystem: "gateway",
+		Name:      "channels",
+		Help:      "number of channels connected to the gateway",
+	}, func() float64 {
+		return float64(channels.Count())
+	}))
+
 	srv.SetReadWait(time.Minute * 2)
 	srv.SetAcceptor(handler)
 	srv.SetMessageListener(handler)
 	srv.SetStateListener(handler)
 
 	_ = container.Init(srv, wire.SNChat, wire.SNLogin)
-
-	ns, err := consul.NewNaming(config.ConsulURL)
-	if err != nil {
-		return err
-	}
 	container.SetServiceNaming(ns)
+	container.SetShutdownTimeout(config.DrainTimeout)
 
 	// set a dialer
 	container.SetDialer(serv.NewDialer(config.ServiceID))
 
+	go serv.TouchLoop(ctx, config.ServiceID, channels, config.SessionTouchInterval, serv.DefaultTouchBatchSize)
+
 	return container.Start()
 }
@@ -5,6 +5,7 @@ import (
 	"github.com/go-redis/redis/v7"
 	"github.com/kelseyhightower/envconfig"
 	"github.com/sirupsen/logrus"
+	"github.com/sjmshsh/HopeIM"
 	"github.com/sjmshsh/HopeIM/logger"
 	"github.com/spf13/viper"
 	"log"
@@ -25,6 +26,52 @@ type Config struct {
 	ConsulURL     string   `envconfig:"consulURL"`
 	RedisAddrs    string   `envconfig:"redisAddrs"`
 	RpcURL        string   `envconfig:"ppcURL"`
+	// RequestTimeout 每个指令的默认处理超时
+	RequestTimeout time.Duration `envconfig:"requestTimeout"`
+	// RateLimit 上行指令的限流
+	RateLimit RateLimit `envconfig:"rateLimit"`
+	// Delivery 消息推送的重传，未配置时使用默认值
+	Delivery HopeIM.DeliveryOptions `envconfig:"delivery"`
+	// DedupWindow 按照客户端消息ID去重的时间窗口
+	DedupWindow time.Duration `envconfig:"dedupWindow"`
+	// DevicePolicies 每个app的多设备登录策略
+	DevicePolicies HopeIM.DevicePolicies `envconfig:"devicePolicies"`
+	// SignalTTL 每种信令的有效期
+	SignalTTL HopeIM.SignalTTLs `envconfig:"signalTTL"`
+}
+
+// RateLimit 限流配置
+type RateLimit struct {
+	// Backend memory或者redis，为空时只对信令指令按照DefaultSignalRateLimits在单个节点上限流
+	Backend  string `envconfig:"backend"`
+	Account  HopeIM.RateLimit
+	App      HopeIM.RateLimit
+	Commands []CommandRateLimit
+}
+
+// CommandRateLimit 单个账号指定指令的限流
+type CommandRateLimit struct {
+	Command string
+	Rate    float64
+	Burst   int
+}
+
+// Options 转换为HopeIM.RateLimitOptions，没有配置的信令指令使用HopeIM.DefaultSignalRateLimits
+func (r RateLimit) Options() HopeIM.RateLimitOptions {
+	opts := HopeIM.RateLimitOptions{
+		Account:  r.Account,
+		App:      r.App,
+		Commands: make(map[string]HopeIM.RateLimit, len(r.Commands)),
+	}
+	for _, c := range r.Commands {
+		opts.Commands[c.Command] = HopeIM.RateLimit{Rate: c.Rate, Burst: c.Burst}
+	}
+	for command, limit := range HopeIM.DefaultSignalRateLimits {
+		if _, ok := opts.Commands[command]; !ok {
+			opts.Commands[command] = limit
+		}
+	}
+	return opts
 }
 
 // Init InitConfig
@@ -46,6 +93,9 @@ func Init(file string) (*Config, error) {
 	if err != nil {
 		return nil, err
 	}
+	if err := config.DevicePolicies.Validate(); err != nil {
+		return nil, err
+	}
 	logger.Info(config)
 
 	return &config, nil
@@ -3,6 +3,7 @@ package handler
 import (
 	"errors"
 	"github.com/sjmshsh/HopeIM"
+	"github.com/sjmshsh/HopeIM/logger"
 	"github.com/sjmshsh/HopeIM/services/server/service"
 	"github.com/sjmshsh/HopeIM/wire/pkt"
 	"github.com/sjmshsh/HopeIM/wire/rpc"
@@ -14,10 +15,19 @@ var ErrNoDestination = errors.New("dest is empty")
 type ChatHandler struct {
 	msgService   service.Message
 	groupService service.Group
+	// delivery 为nil时推送不跟踪ack
+	delivery *HopeIM.Delivery
+	// deduper 为nil时不按照客户端消息ID去重
+	deduper *MessageDeduper
 }
 
-func NewChatHandler() *ChatHandler {
-	return &ChatHandler{}
+func NewChatHandler(message service.Message, group service.Group, delivery *HopeIM.Delivery, deduper *MessageDeduper) *ChatHandler {
+	return &ChatHandler{
+		msgService:   message,
+		groupService: group,
+		delivery:     delivery,
+		deduper:      deduper,
+	}
 }
 
 func (h *ChatHandler) DoUserTalk(ctx HopeIM.Context) {
@@ -32,16 +42,19 @@ func (h *ChatHandler) DoUserTalk(ctx HopeIM.Context) {
 		_ = ctx.RespWithError(pkt.Status_InvalidPacketBody, err)
 		return
 	}
-	// 2. 获取接收方的位置信息
+	if h.respDuplicate(ctx, req.GetClientMsgId()) {
+		return
+	}
+	// 2. 获取接收方所有设备的位置信息
 	receiver := ctx.Header().GetDest()
-	loc, err := ctx.GetLocation(receiver, "")
+	locs, err := ctx.GetLocations(receiver)
 	if err != nil && err != HopeIM.ErrSessionNil {
 		_ = ctx.RespWithError(pkt.Status_SystemException, err)
 		return
 	}
 	// 3. 保存离线消息
 	sendTime := time.Now().UnixNano()
-	resp, err := h.msgService.InsertUser(ctx.Session().GetApp(), &rpc.InsertMessageReq{
+	resp, err := h.msgService.InsertUser(ctx, ctx.Session().GetApp(), &rpc.InsertMessageReq{
 		Sender:   ctx.Session().GetAccount(),
 		Dest:     receiver,
 		SendTime: sendTime,
@@ -50,29 +63,34 @@ func (h *ChatHandler) DoUserTalk(ctx HopeIM.Context) {
 			Body:  req.GetBody(),
 			Extra: req.GetExtra(),
 		},
+		ClientMsgId: req.GetClientMsgId(),
 	})
 	if err != nil {
 		_ = ctx.RespWithError(pkt.Status_SystemException, err)
 		return
 	}
 	msgId := resp.MessageId
+	if resp.Duplicate {
+		// 消息服务去重命中，使用第一次保存的发送时间
+		sendTime = resp.SendTime
+	}
 
-	// 4. 如果接收方在线，就推送一条消息过去
-	if loc != nil {
-		if err = ctx.Dispatch(&pkt.MessagePush{
+	// 4. 如果接收方在线，就推送到它的每一个设备
+	if len(locs) > 0 {
+		if err = h.dispatch(ctx, msgId, &pkt.MessagePush{
 			MessageId: msgId,
 			Type:      req.GetType(),
 			Body:      req.GetBody(),
 			Extra:     req.GetExtra(),
 			Sender:    ctx.Session().GetAccount(),
 			SendTime:  sendTime,
-		}, loc); err != nil {
+		}, locs...); err != nil && !isDispatchError(err) {
 			_ = ctx.RespWithError(pkt.Status_SystemException, err)
 			return
 		}
 	}
 	// 5. 返回一条resp消息
-	_ = ctx.Resp(pkt.Status_Success, &pkt.MessageResp{
+	h.resp(ctx, req.GetClientMsgId(), &pkt.MessageResp{
 		MessageId: msgId,
 		SendTime:  sendTime,
 	})
@@ -89,12 +107,15 @@ func (h *ChatHandler) DoGroupTalk(ctx HopeIM.Context) {
 		_ = ctx.RespWithError(pkt.Status_InvalidPacketBody, err)
 		return
 	}
+	if h.respDuplicate(ctx, req.GetClientMsgId()) {
+		return
+	}
 	// 群聊里dest就不再是user account，而是群ID
 	group := ctx.Header().GetDest()
 	sendTime := time.Now().UnixNano()
 
 	// 2. 保存离线消息
-	resp, err := h.msgService.InsertGroup(ctx.Session().GetApp(), &rpc.InsertMessageReq{
+	resp, err := h.msgService.InsertGroup(ctx, ctx.Session().GetApp(), &rpc.InsertMessageReq{
 		Sender:   ctx.Session().GetAccount(),
 		Dest:     group,
 		SendTime: sendTime,
@@ -103,13 +124,17 @@ func (h *ChatHandler) DoGroupTalk(ctx HopeIM.Context) {
 			Body:  req.GetBody(),
 			Extra: req.GetExtra(),
 		},
+		ClientMsgId: req.GetClientMsgId(),
 	})
 	if err != nil {
 		_ = ctx.RespWithError(pkt.Status_SystemException, err)
 		return
 	}
+	if resp.Duplicate {
+		sendTime = resp.SendTime
+	}
 	// 3. 读取群成员列表
-	membersResp, err := h.groupService.Members(ctx.Session().GetApp(), &rpc.GroupMembersReq{
+	membersResp, err := h.groupService.Members(ctx, ctx.Session().GetApp(), &rpc.GroupMembersReq{
 		GroupId: group,
 	})
 	if err != nil {
@@ -129,32 +154,85 @@ func (h *ChatHandler) DoGroupTalk(ctx HopeIM.Context) {
 
 	// 5. 批量推送消息给成员
 	if len(locs) > 0 {
-		if err = ctx.Dispatch(&pkt.MessagePush{
+		if err = h.dispatch(ctx, resp.MessageId, &pkt.MessagePush{
 			MessageId: resp.MessageId,
 			Type:      req.GetType(),
 			Body:      req.GetBody(),
 			Extra:     req.GetExtra(),
 			Sender:    ctx.Session().GetAccount(),
 			SendTime:  sendTime,
-		}, locs...); err != nil {
+		}, locs...); err != nil && !isDispatchError(err) {
 			_ = ctx.RespWithError(pkt.Status_SystemException, err)
 			return
 		}
 	}
 	// 6. 返回一条resp消息
-	_ = ctx.Resp(pkt.Status_Success, &pkt.MessageResp{
+	h.resp(ctx, req.GetClientMsgId(), &pkt.MessageResp{
 		MessageId: resp.MessageId,
 		SendTime:  sendTime,
 	})
 }
 
+// respDuplicate 客户端重试的消息已经发送成功过，直接返回第一次的结果
+func (h *ChatHandler) respDuplicate(ctx HopeIM.Context, clientMsgId string) bool {
+	if h.deduper == nil {
+		return false
+	}
+	resp, ok := h.deduper.Get(dedupKeyOf(ctx, clientMsgId))
+	if !ok {
+		return false
+	}
+	_ = ctx.Resp(pkt.Status_Success, resp)
+	return true
+}
+
+func (h *ChatHandler) resp(ctx HopeIM.Context, clientMsgId string, resp *pkt.MessageResp) {
+	if h.deduper != nil {
+		h.deduper.Put(dedupKeyOf(ctx, clientMsgId), resp)
+	}
+	_ = ctx.Resp(pkt.Status_Success, resp)
+}
+
+func dedupKeyOf(ctx HopeIM.Context, clientMsgId string) DedupKey {
+	return DedupKey{
+		App:         ctx.Session().GetApp(),
+		Command:     ctx.Header().GetCommand(),
+		Account:     ctx.Session().GetAccount(),
+		Dest:        ctx.Header().GetDest(),
+		ClientMsgId: clientMsgId,
+	}
+}
+
+// dispatch 开启可靠推送时跟踪每个接收方的ack，超时未确认的消息会被重传
+func (h *ChatHandler) dispatch(ctx HopeIM.Context, messageId int64, push *pkt.MessagePush, locs ...*HopeIM.Location) error {
+	if h.delivery == nil {
+		return ctx.Dispatch(push, locs...)
+	}
+	return h.delivery.Dispatch(ctx, messageId, push, locs...)
+}
+
+// isDispatchError 消息已经保存为离线消息，部分接收方推送失败时由离线同步补偿，不影响发送结果
+func isDispatchError(err error) bool {
+	var derr *HopeIM.DispatchError
+	if errors.As(err, &derr) {
+		logger.WithFields(logger.Fields{
+			"module": "ChatHandler",
+		}).Warnf("%d channels fall back to offline sync: %v", len(derr.FailedChannels()), derr)
+		return true
+	}
+	return false
+}
+
 func (h *ChatHandler) DoTalkAck(ctx HopeIM.Context) {
 	var req pkt.MessageAckReq
 	if err := ctx.ReadBody(&req); err != nil {
 		_ = ctx.RespWithError(pkt.Status_InvalidPacketBody, err)
 		return
 	}
-	err := h.msgService.SetAck(ctx.Session().GetApp(), &rpc.AckMessageReq{
+	if h.delivery != nil {
+		h.delivery.Ack(ctx.Session().GetChannelId(), req.GetMessageId())
+	}
+	err := h.msgService.SetAck(ctx, ctx.Session().GetApp(), &rpc.AckMessageReq{
 		Account:   ctx.Session().GetAccount(),
 		MessageId: req.GetMessageId(),
 	})
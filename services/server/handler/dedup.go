@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sjmshsh/HopeIM/wire/pkt"
+)
+
+// DefaultDedupWindow 客户端消息ID的默认去重时间窗口
+const DefaultDedupWindow = time.Minute * 5
+
+// DedupKey 客户端消息ID只在同一个app、同一类消息与同一个会话中唯一
+type DedupKey struct {
+	App     string
+	Command string
+	Account string
+	Dest    string
+	// ClientMsgId 客户端生成的消息ID
+	ClientMsgId string
+}
+
+// MessageDeduper 按照DedupKey缓存发送结果，客户端超时重试时直接返回第一次的MessageResp。
+// 使用两代map轮换，一条记录至少保留window，最多保留2*window
+type MessageDeduper struct {
+	sync.Mutex
+	window    time.Duration
+	current   map[DedupKey]*pkt.MessageResp
+	previous  map[DedupKey]*pkt.MessageResp
+	rotatedAt time.Time
+}
+
+// NewMessageDeduper NewMessageDeduper
+func NewMessageDeduper(window time.Duration) *MessageDeduper {
+	if window <= 0 {
+		window = DefaultDedupWindow
+	}
+	return &MessageDeduper{
+		window:    window,
+		current:   make(map[DedupKey]*pkt.MessageResp),
+		previous:  make(map[DedupKey]*pkt.MessageResp),
+		rotatedAt: time.Now(),
+	}
+}
+
+// Get 返回第一次发送的结果，ClientMsgId为空时不去重
+func (d *MessageDeduper) Get(key DedupKey) (*pkt.MessageResp, bool) {
+	if key.ClientMsgId == "" {
+		return nil, false
+	}
+	d.Lock()
+	defer d.Unlock()
+	d.rotate()
+	if resp, ok := d.current[key]; ok {
+		return resp, true
+	}
+	resp, ok := d.previous[key]
+	return resp, ok
+}
+
+// Put 记录发送成功的结果
+func (d *MessageDeduper) Put(key DedupKey, resp *pkt.MessageResp) {
+	if key.ClientMsgId == "" {
+		return
+	}
+	d.Lock()
+	defer d.Unlock()
+	d.rotate()
+	d.current[key] = resp
+}
+
+func (d *MessageDeduper) rotate() {
+	now := time.Now()
+	if now.Sub(d.rotatedAt) < d.window {
+		return
+	}
+	// 超过两个窗口没有写入时，两代都已经过期
+	if now.Sub(d.rotatedAt) >= d.window*2 {
+		d.previous = make(map[DedupKey]*pkt.MessageResp)
+	} else {
+		d.previous = d.current
+	}
+	d.current = make(map[DedupKey]*pkt.MessageResp)
+	d.rotatedAt = now
+}
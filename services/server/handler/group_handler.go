@@ -23,7 +23,7 @@ func (h *GroupHandler) DoCreate(ctx HopeIM.Context) {
 		_ = ctx.RespWithError(pkt.Status_InvalidPacketBody, err)
 		return
 	}
-	resp, err := h.groupService.Create(ctx.Session().GetApp(), &rpc.CreateGroupReq{
+	resp, err := h.groupService.Create(ctx, ctx.Session().GetApp(), &rpc.CreateGroupReq{
 		Name:         req.GetName(),
 		Avatar:       req.GetAvatar(),
 		Introduction: req.GetIntroduction(),
@@ -62,7 +62,7 @@ func (h *GroupHandler) DoJoin(ctx HopeIM.Context) {
 		_ = ctx.RespWithError(pkt.Status_InvalidPacketBody, err)
 		return
 	}
-	err := h.groupService.Join(ctx.Session().GetApp(), &rpc.JoinGroupReq{
+	err := h.groupService.Join(ctx, ctx.Session().GetApp(), &rpc.JoinGroupReq{
 		Account: req.Account,
 		GroupId: req.GetGroupId(),
 	})
@@ -80,7 +80,7 @@ func (h *GroupHandler) DoQuit(ctx HopeIM.Context) {
 		_ = ctx.RespWithError(pkt.Status_InvalidPacketBody, err)
 		return
 	}
-	err := h.groupService.Quit(ctx.Session().GetApp(), &rpc.QuitGroupReq{
+	err := h.groupService.Quit(ctx, ctx.Session().GetApp(), &rpc.QuitGroupReq{
 		Account: req.Account,
 		GroupId: req.GetGroupId(),
 	})
@@ -97,14 +97,14 @@ func (h *GroupHandler) DoDetail(ctx HopeIM.Context) {
 		_ = ctx.RespWithError(pkt.Status_InvalidPacketBody, err)
 		return
 	}
-	resp, err := h.groupService.Detail(ctx.Session().GetApp(), &rpc.GetGroupReq{
+	resp, err := h.groupService.Detail(ctx, ctx.Session().GetApp(), &rpc.GetGroupReq{
 		GroupId: req.GetGroupId(),
 	})
 	if err != nil {
 		_ = ctx.RespWithError(pkt.Status_SystemException, err)
 		return
 	}
-	membersResp, err := h.groupService.Members(ctx.Session().GetApp(), &rpc.GroupMembersReq{
+	membersResp, err := h.groupService.Members(ctx, ctx.Session().GetApp(), &rpc.GroupMembersReq{
 		GroupId: req.GetGroupId(),
 	})
 	if err != nil {
@@ -6,10 +6,21 @@ import (
 	"github.com/sjmshsh/HopeIM/wire/pkt"
 )
 
-type LoginHandler struct{}
+type LoginHandler struct {
+	// policies 每个app的多设备登录策略
+	policies HopeIM.DevicePolicies
+	// presence 为nil时不推送在线状态的变化
+	presence *PresenceHandler
+	// delivery 会话退出或者被踢下线时丢弃它等待ack的推送，可以为nil
+	delivery *HopeIM.Delivery
+}
 
-func NewLoginHandler() *LoginHandler {
-	return &LoginHandler{}
+func NewLoginHandler(policies HopeIM.DevicePolicies, presence *PresenceHandler, delivery *HopeIM.Delivery) *LoginHandler {
+	return &LoginHandler{
+		policies: policies,
+		presence: presence,
+		delivery: delivery,
+	}
 }
 
 func (h *LoginHandler) DoSysLogin(ctx HopeIM.Context) {
@@ -25,20 +36,27 @@ func (h *LoginHandler) DoSysLogin(ctx HopeIM.Context) {
 		"ChannelId": session.GetChannelId(),
 		"Account":   session.GetAccount(),
 		"RemoteIP":  session.GetRemoteIP(),
+		"Device":    session.GetDevice(),
 	}).Info("do login")
 
-	// 2. 检查当前账号是否已经登录在其他地方
-	old, err := ctx.GetLocation(session.Account, "")
+	// 2. 检查当前账号已经登录的设备
+	olds, err := ctx.GetLocations(session.Account)
 	if err != nil && err != HopeIM.ErrSessionNil {
 		_ = ctx.RespWithError(pkt.Status_SystemException, err)
 		return
 	}
 
-	if old != nil {
-		// 3. 通知这个用户下线
+	// 3. 按照app的策略通知冲突的设备下线
+	conflicts := h.policies.Of(session.App).Conflicts(session.Device, olds)
+	for _, old := range conflicts {
 		_ = ctx.Dispatch(&pkt.KickoutNotify{
 			ChannelId: old.ChannelId,
 		}, old)
+		if err = ctx.Delete(session.Account, old.ChannelId); err != nil {
+			_ = ctx.RespWithError(pkt.Status_SystemException, err)
+			return
+		}
+		h.forget(old.ChannelId)
 	}
 
 	// 4. 添加到会话管理器中
@@ -52,6 +70,13 @@ func (h *LoginHandler) DoSysLogin(ctx HopeIM.Context) {
 		ChannelId: session.ChannelId,
 	}
 	_ = ctx.Resp(pkt.Status_Success, resp)
+
+	// 6. 账号从离线变为在线时通知订阅者，踢掉旧设备重新登录时账号一直在线
+	if h.presence != nil && len(olds) == 0 {
+		if err = h.presence.Notify(ctx, session.Account); err != nil {
+			logger.WithField("Func", "Login").Warn(err)
+		}
+	}
 }
 
 func (h *LoginHandler) DoSysLogout(ctx HopeIM.Context) {
@@ -61,11 +86,60 @@ func (h *LoginHandler) DoSysLogout(ctx HopeIM.Context) {
 		"Account":   ctx.Session().GetAccount(),
 	}).Info("do Logout ")
 
-	err := ctx.Delete(ctx.Session().GetAccount(), ctx.Session().GetChannelId())
+	account := ctx.Session().GetAccount()
+	err := ctx.Delete(account, ctx.Session().GetChannelId())
 	if err != nil {
 		_ = ctx.RespWithError(pkt.Status_SystemException, err)
 		return
 	}
+	h.forget(ctx.Session().GetChannelId())
 
 	_ = ctx.Resp(pkt.Status_Success, nil)
-}
\ No newline at end of file
+
+	// 最后一个设备退出之后账号变为离线，清除自定义状态并通知订阅者
+	if h.presence == nil {
+		return
+	}
+	if _, err = ctx.GetLocations(account); err != HopeIM.ErrSessionNil {
+		return
+	}
+	if err = h.presence.storage.SetStatus(account, ""); err != nil {
+		logger.WithField("Func", "Logout").Warn(err)
+	}
+	if err = h.presence.Notify(ctx, account); err != nil {
+		logger.WithField("Func", "Logout").Warn(err)
+	}
+}
+
+func (h *LoginHandler) forget(channelId string) {
+	if h.delivery != nil {
+		h.delivery.Forget(channelId)
+	}
+}
+
+// DoSysTouch 刷新网关上报的在线会话的过期时间，网关不需要响应
+func (h *LoginHandler) DoSysTouch(ctx HopeIM.Context) {
+	var req pkt.SessionTouchReq
+	if err := ctx.ReadBody(&req); err != nil {
+		logger.WithField("Func", "Touch").Warn(err)
+		return
+	}
+	// 已经在其它网关上重新登录的会话由新的网关续期
+	sessions, err := ctx.Touch(ctx.Session().GetGateId(), req.Sessions...)
+	if err != nil {
+		logger.WithFields(logger.Fields{
+			"Func":   "Touch",
+			"GateId": ctx.Session().GetGateId(),
+		}).Warn(err)
+		return
+	}
+	if h.presence == nil || len(sessions) == 0 {
+		return
+	}
+	if err := h.presence.Touch(sessions...); err != nil {
+		logger.WithFields(logger.Fields{
+			"Func":   "Touch",
+			"GateId": ctx.Session().GetGateId(),
+		}).Warn(err)
+	}
+}
@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/sjmshsh/HopeIM/services/server/service"
+	"github.com/sjmshsh/HopeIM/wire/rpc"
+)
+
+// DefaultMembersExpired 群成员列表的缓存时间，成员变化最多延迟这么久生效
+const DefaultMembersExpired = time.Second * 30
+
+// MembersCache 缓存群成员列表，避免每条群信令都请求群服务
+type MembersCache struct {
+	sync.Mutex
+	group     service.Group
+	expired   time.Duration
+	groups    map[string]*groupMembers
+	lastSweep time.Time
+}
+
+type groupMembers struct {
+	accounts []string
+	members  map[string]struct{}
+	expireAt time.Time
+}
+
+// NewMembersCache expired为0时使用DefaultMembersExpired
+func NewMembersCache(group service.Group, expired time.Duration) *MembersCache {
+	if expired <= 0 {
+		expired = DefaultMembersExpired
+	}
+	return &MembersCache{
+		group:     group,
+		expired:   expired,
+		groups:    make(map[string]*groupMembers),
+		lastSweep: time.Now(),
+	}
+}
+
+// Members 返回群成员列表，以及account是否为群成员
+func (c *MembersCache) Members(ctx context.Context, app, groupId, account string) ([]string, bool, error) {
+	key := app + "\x00" + groupId
+	now := time.Now()
+	c.Lock()
+	c.sweep(now)
+	g, ok := c.groups[key]
+	c.Unlock()
+	if !ok || !now.Before(g.expireAt) {
+		resp, err := c.group.Members(ctx, app, &rpc.GroupMembersReq{
+			GroupId: groupId,
+		})
+		if err != nil {
+			return nil, false, err
+		}
+		g = &groupMembers{
+			accounts: make([]string, len(resp.Users)),
+			members:  make(map[string]struct{}, len(resp.Users)),
+			expireAt: now.Add(c.expired),
+		}
+		for i, user := range resp.Users {
+			g.accounts[i] = user.Account
+			g.members[user.Account] = struct{}{}
+		}
+		c.Lock()
+		c.groups[key] = g
+		c.Unlock()
+	}
+	_, ok = g.members[account]
+	return g.accounts, ok, nil
+}
+
+// sweep 每隔一个expired清理一次过期的群
+func (c *MembersCache) sweep(now time.Time) {
+	if now.Sub(c.lastSweep) < c.expired {
+		return
+	}
+	c.lastSweep = now
+	for key, g := range c.groups {
+		if !now.Before(g.expireAt) {
+			delete(c.groups, key)
+		}
+	}
+}
@@ -24,7 +24,7 @@ func (h *OfflineHandler) DoSyncIndex(ctx HopeIM.Context) {
 		_ = ctx.RespWithError(pkt.Status_InvalidPacketBody, err)
 		return
 	}
-	resp, err := h.msgService.GetMessageIndex(ctx.Session().GetApp(), &rpc.GetOfflineMessageIndexReq{
+	resp, err := h.msgService.GetMessageIndex(ctx, ctx.Session().GetApp(), &rpc.GetOfflineMessageIndexReq{
 		Account:   ctx.Session().GetAccount(),
 		MessageId: req.GetMessageId(),
 	})
@@ -57,7 +57,7 @@ func (h *OfflineHandler) DoSyncContent(ctx HopeIM.Context) {
 		_ = ctx.RespWithError(pkt.Status_InvalidPacketBody, errors.New("empty MessageIds"))
 		return
 	}
-	resp, err := h.msgService.GetMessageContent(ctx.Session().GetApp(), &rpc.GetOfflineMessageContentReq{
+	resp, err := h.msgService.GetMessageContent(ctx, ctx.Session().GetApp(), &rpc.GetOfflineMessageContentReq{
 		MessageIds: req.MessageIds,
 	})
 	if err != nil {
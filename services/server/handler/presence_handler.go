@@ -0,0 +1,180 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/sjmshsh/HopeIM"
+	"github.com/sjmshsh/HopeIM/logger"
+	"github.com/sjmshsh/HopeIM/wire"
+	"github.com/sjmshsh/HopeIM/wire/pkt"
+)
+
+// PresenceNotifyBatch 推送状态变化时每批读取Location的订阅者数量
+const PresenceNotifyBatch = 500
+
+var ErrInvalidPresenceStatus = errors.New("err:invalid presence status")
+
+type PresenceHandler struct {
+	storage HopeIM.PresenceStorage
+}
+
+func NewPresenceHandler(storage HopeIM.PresenceStorage) *PresenceHandler {
+	return &PresenceHandler{
+		storage: storage,
+	}
+}
+
+// DoQuery 查询账号的在线状态
+func (h *PresenceHandler) DoQuery(ctx HopeIM.Context) {
+	var req pkt.PresenceQueryReq
+	if err := ctx.ReadBody(&req); err != nil {
+		_ = ctx.RespWithError(pkt.Status_InvalidPacketBody, err)
+		return
+	}
+	if err := checkAccounts(req.Accounts); err != nil {
+		_ = ctx.RespWithError(pkt.Status_InvalidPacketBody, err)
+		return
+	}
+	presences, err := h.query(ctx, req.Accounts)
+	if err != nil {
+		_ = ctx.RespWithError(pkt.Status_SystemException, err)
+		return
+	}
+	_ = ctx.Resp(pkt.Status_Success, &pkt.PresenceQueryResp{
+		Presences: presences,
+	})
+}
+
+// DoSubscribe 订阅或者取消订阅账号的在线状态，订阅成功时返回它们当前的状态
+func (h *PresenceHandler) DoSubscribe(ctx HopeIM.Context) {
+	var req pkt.PresenceSubscribeReq
+	if err := ctx.ReadBody(&req); err != nil {
+		_ = ctx.RespWithError(pkt.Status_InvalidPacketBody, err)
+		return
+	}
+	if err := checkAccounts(req.Accounts); err != nil {
+		_ = ctx.RespWithError(pkt.Status_InvalidPacketBody, err)
+		return
+	}
+	subscriber := ctx.Session().GetAccount()
+	if req.Unsubscribe {
+		if err := h.storage.Unsubscribe(subscriber, req.Accounts...); err != nil {
+			_ = ctx.RespWithError(pkt.Status_SystemException, err)
+			return
+		}
+		_ = ctx.Resp(pkt.Status_Success, nil)
+		return
+	}
+	if err := h.storage.Subscribe(subscriber, req.Accounts...); err != nil {
+		_ = ctx.RespWithError(pkt.Status_SystemException, err)
+		return
+	}
+	presences, err := h.query(ctx, req.Accounts)
+	if err != nil {
+		_ = ctx.RespWithError(pkt.Status_SystemException, err)
+		return
+	}
+	_ = ctx.Resp(pkt.Status_Success, &pkt.PresenceQueryResp{
+		Presences: presences,
+	})
+}
+
+// DoSetStatus 设置自定义状态，设置为online时清除自定义状态
+func (h *PresenceHandler) DoSetStatus(ctx HopeIM.Context) {
+	var req pkt.PresenceSetReq
+	if err := ctx.ReadBody(&req); err != nil {
+		_ = ctx.RespWithError(pkt.Status_InvalidPacketBody, err)
+		return
+	}
+	var status string
+	switch req.Status {
+	case wire.PresenceOnline:
+	case wire.PresenceAway, wire.PresenceBusy:
+		status = req.Status
+	default:
+		_ = ctx.RespWithError(pkt.Status_InvalidPacketBody, ErrInvalidPresenceStatus)
+		return
+	}
+	account := ctx.Session().GetAccount()
+	if err := h.storage.SetStatus(account, status); err != nil {
+		_ = ctx.RespWithError(pkt.Status_SystemException, err)
+		return
+	}
+	_ = ctx.Resp(pkt.Status_Success, nil)
+
+	if err := h.Notify(ctx, account); err != nil {
+		logger.WithField("Func", "SetStatus").Warn(err)
+	}
+}
+
+// Notify 读取account当前的状态，以CommandPresenceNotify分批推送给它的订阅者
+func (h *PresenceHandler) Notify(ctx HopeIM.Context, account string) error {
+	presences, err := h.query(ctx, []string{account})
+	if err != nil {
+		return err
+	}
+	subscribers, err := h.storage.Subscribers(account)
+	if err != nil {
+		return err
+	}
+	notify := &pkt.PresenceNotify{
+		Presences: presences,
+	}
+	for i := 0; i < len(subscribers); i += PresenceNotifyBatch {
+		end := i + PresenceNotifyBatch
+		if end > len(subscribers) {
+			end = len(subscribers)
+		}
+		locs, err := ctx.GetLocations(subscribers[i:end]...)
+		if err == HopeIM.ErrSessionNil {
+			continue
+		} else if err != nil {
+			return err
+		}
+		if err = ctx.Notify(wire.CommandPresenceNotify, notify, locs...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Touch 刷新在线账号的在线状态数据，与会话一起续期
+func (h *PresenceHandler) Touch(sessions ...*pkt.TouchSession) error {
+	accounts := make([]string, 0, len(sessions))
+	seen := make(map[string]struct{}, len(sessions))
+	for _, sn := range sessions {
+		if _, ok := seen[sn.Account]; ok {
+			continue
+		}
+		seen[sn.Account] = struct{}{}
+		accounts = append(accounts, sn.Account)
+	}
+	return h.storage.Touch(accounts...)
+}
+
+func (h *PresenceHandler) query(ctx HopeIM.Context, accounts []string) ([]*pkt.Presence, error) {
+	status, err := h.storage.GetStatus(accounts...)
+	if err != nil {
+		return nil, err
+	}
+	presences := make([]*pkt.Presence, len(accounts))
+	for i, account := range accounts {
+		locs, err := ctx.GetLocations(account)
+		if err != nil && err != HopeIM.ErrSessionNil {
+			return nil, err
+		}
+		presences[i] = HopeIM.PresenceOf(account, locs, status[account])
+	}
+	return presences, nil
+}
+
+func checkAccounts(accounts []string) error {
+	if len(accounts) == 0 {
+		return errors.New("empty Accounts")
+	}
+	if len(accounts) > wire.PresenceMaxAccounts {
+		return fmt.Errorf("too many accounts, max %d", wire.PresenceMaxAccounts)
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v7"
+	"github.com/sjmshsh/HopeIM"
+	"github.com/sjmshsh/HopeIM/storage"
+	"github.com/sjmshsh/HopeIM/wire"
+	"github.com/sjmshsh/HopeIM/wire/pkt"
+	"github.com/stretchr/testify/assert"
+)
+
+type testPush struct {
+	gateway  string
+	channels []string
+	packet   *pkt.LogicPkt
+}
+
+// testDispather 记录推送的消息，包括返回给网关的Resp
+type testDispather struct {
+	sync.Mutex
+	pushes []testPush
+}
+
+func (d *testDispather) Push(ctx context.Context, gateway string, channels []string, p *pkt.LogicPkt) error {
+	d.Lock()
+	defer d.Unlock()
+	d.pushes = append(d.pushes, testPush{gateway, channels, p})
+	return nil
+}
+
+// commands 返回指令为command的推送
+func (d *testDispather) commands(command string) []testPush {
+	d.Lock()
+	defer d.Unlock()
+	var pushes []testPush
+	for _, p := range d.pushes {
+		if p.packet.Command == command {
+			pushes = append(pushes, p)
+		}
+	}
+	return pushes
+}
+
+// countStorage 记录GetLocations每次查询的账号数
+type countStorage struct {
+	HopeIM.SessionStorage
+	sync.Mutex
+	batches []int
+}
+
+func (s *countStorage) GetLocations(accounts ...string) ([]*HopeIM.Location, error) {
+	s.Lock()
+	s.batches = append(s.batches, len(accounts))
+	s.Unlock()
+	return s.SessionStorage.GetLocations(accounts...)
+}
+
+func newPresenceHandler(t *testing.T) *PresenceHandler {
+	mr := miniredis.RunT(t)
+	cli := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = cli.Close() })
+	return NewPresenceHandler(storage.NewRedisPresenceStorage(cli))
+}
+
+func signin(r *HopeIM.Router, d HopeIM.Dispather, cache HopeIM.SessionStorage, session *pkt.Session) {
+	p := pkt.New(wire.CommandLoginSignIn, pkt.WithChannel(session.ChannelId))
+	p.WriteBody(session)
+	_ = r.Serve(p, d, cache, &pkt.Session{ChannelId: session.ChannelId, GateId: session.GateId})
+}
+
+func signout(r *HopeIM.Router, d HopeIM.Dispather, cache HopeIM.SessionStorage, channelId string) {
+	session, _ := cache.Get(channelId)
+	_ = r.Serve(pkt.New(wire.CommandLoginSignOut, pkt.WithChannel(channelId)), d, cache, session)
+}
+
+func readNotify(t *testing.T, p testPush) *pkt.Presence {
+	assert.Equal(t, pkt.Flag_Push, p.packet.Flag)
+	var notify pkt.PresenceNotify
+	assert.Nil(t, p.packet.ReadBody(&notify))
+	assert.Len(t, notify.Presences, 1)
+	return notify.Presences[0]
+}
+
+func TestPresenceHandler_LoginLogout(t *testing.T) {
+	presence := newPresenceHandler(t)
+	login := NewLoginHandler(HopeIM.DevicePolicies{Default: HopeIM.DevicePolicyUnlimited}, presence, nil)
+	r := HopeIM.NewRouter()
+	r.Handle(wire.CommandLoginSignIn, login.DoSysLogin)
+	r.Handle(wire.CommandLoginSignOut, login.DoSysLogout)
+	cache := storage.NewMemoryStorage(0)
+	d := &testDispather{}
+
+	assert.Nil(t, cache.Add(&pkt.Session{ChannelId: "sub1", GateId: "gate1", Account: "sub1"}))
+	assert.Nil(t, presence.storage.Subscribe("sub1", "test1"))
+
+	// 离线 -> 在线
+	signin(r, d, cache, &pkt.Session{ChannelId: "ch1", GateId: "gate1", Account: "test1", Device: wire.DeviceIOS})
+	pushes := d.commands(wire.CommandPresenceNotify)
+	assert.Len(t, pushes, 1)
+	assert.Equal(t, []string{"sub1"}, pushes[0].channels)
+	assert.Equal(t, wire.PresenceOnline, readNotify(t, pushes[0]).Status)
+
+	// 第二个设备登录与第一个设备退出都不改变在线状态
+	signin(r, d, cache, &pkt.Session{ChannelId: "ch2", GateId: "gate1", Account: "test1", Device: wire.DevicePC})
+	signout(r, d, cache, "ch1")
+	assert.Len(t, d.commands(wire.CommandPresenceNotify), 1)
+
+	// 最后一个设备退出，在线 -> 离线
+	signout(r, d, cache, "ch2")
+	pushes = d.commands(wire.CommandPresenceNotify)
+	assert.Len(t, pushes, 2)
+	assert.Equal(t, wire.PresenceOffline, readNotify(t, pushes[1]).Status)
+}
+
+func TestPresenceHandler_NotifyBatch(t *testing.T) {
+	presence := newPresenceHandler(t)
+	cache := &countStorage{SessionStorage: storage.NewMemoryStorage(0)}
+	total := PresenceNotifyBatch*2 + 10
+	for i := 0; i < total; i++ {
+		account := fmt.Sprintf("sub%d", i)
+		assert.Nil(t, cache.Add(&pkt.Session{ChannelId: account, GateId: "gate1", Account: account}))
+		assert.Nil(t, presence.storage.Subscribe(account, "test1"))
+	}
+	assert.Nil(t, cache.Add(&pkt.Session{ChannelId: "ch1", GateId: "gate1", Account: "test1"}))
+
+	r := HopeIM.NewRouter()
+	r.Handle(wire.CommandPresenceSet, presence.DoSetStatus)
+	d := &testDispather{}
+	p := pkt.New(wire.CommandPresenceSet, pkt.WithChannel("ch1"))
+	p.WriteBody(&pkt.PresenceSetReq{Status: wire.PresenceBusy})
+	session, _ := cache.Get("ch1")
+	_ = r.Serve(p, d, cache, session)
+
+	// 订阅者分批寻址，每批单独推送
+	var batches []int
+	for _, n := range cache.batches {
+		if n > 1 {
+			batches = append(batches, n)
+		}
+	}
+	assert.Equal(t, []int{PresenceNotifyBatch, PresenceNotifyBatch, 10}, batches)
+	pushes := d.commands(wire.CommandPresenceNotify)
+	assert.Len(t, pushes, 3)
+	var channels int
+	for _, push := range pushes {
+		channels += len(push.channels)
+		assert.Equal(t, wire.PresenceBusy, readNotify(t, push).Status)
+	}
+	assert.Equal(t, total, channels)
+}
+
+func TestPresenceHandler_LoginKickout(t *testing.T) {
+	presence := newPresenceHandler(t)
+	login := NewLoginHandler(HopeIM.DevicePolicies{}, presence, nil)
+	r := HopeIM.NewRouter()
+	r.Handle(wire.CommandLoginSignIn, login.DoSysLogin)
+	cache := storage.NewMemoryStorage(0)
+	d := &testDispather{}
+
+	assert.Nil(t, cache.Add(&pkt.Session{ChannelId: "sub1", GateId: "gate1", Account: "sub1"}))
+	assert.Nil(t, presence.storage.Subscribe("sub1", "test1"))
+
+	signin(r, d, cache, &pkt.Session{ChannelId: "ch1", GateId: "gate1", Account: "test1", Device: wire.DeviceIOS})
+	assert.Len(t, d.commands(wire.CommandPresenceNotify), 1)
+
+	// 同类型设备登录踢掉旧的会话，账号一直在线
+	signin(r, d, cache, &pkt.Session{ChannelId: "ch2", GateId: "gate1", Account: "test1", Device: wire.DeviceIOS})
+	assert.Len(t, d.commands(wire.CommandPresenceNotify), 1)
+	_, err := cache.Get("ch1")
+	assert.Equal(t, HopeIM.ErrSessionNil, err)
+}
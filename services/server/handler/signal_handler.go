@@ -0,0 +1,144 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/sjmshsh/HopeIM"
+	"github.com/sjmshsh/HopeIM/logger"
+	"github.com/sjmshsh/HopeIM/services/server/service"
+	"github.com/sjmshsh/HopeIM/wire"
+	"github.com/sjmshsh/HopeIM/wire/pkt"
+)
+
+var (
+	ErrEmptySignalType = errors.New("err:signal type is empty")
+	ErrNotGroupMember  = errors.New("err:sender is not a member of the group")
+)
+
+// SignalHandler 信令只推送给当前在线的接收方，不保存也不跟踪ack。
+// 发送成功时不返回resp，只有请求无效或者出错时才返回错误
+type SignalHandler struct {
+	members *MembersCache
+	ttls    HopeIM.SignalTTLs
+}
+
+func NewSignalHandler(group service.Group, ttls HopeIM.SignalTTLs) *SignalHandler {
+	return &SignalHandler{
+		members: NewMembersCache(group, DefaultMembersExpired),
+		ttls:    ttls,
+	}
+}
+
+func (h *SignalHandler) DoUserSignal(ctx HopeIM.Context) {
+	if ctx.Header().GetDest() == "" {
+		_ = ctx.RespWithError(pkt.Status_NoDestination, ErrNoDestination)
+		return
+	}
+	req, ok := h.readSignal(ctx)
+	if !ok {
+		return
+	}
+	expiresAt := h.expiresAt(ctx, req.Type)
+
+	locs, err := ctx.GetLocations(ctx.Header().GetDest())
+	if err == HopeIM.ErrSessionNil {
+		return
+	} else if err != nil {
+		_ = ctx.RespWithError(pkt.Status_SystemException, err)
+		return
+	}
+	h.dispatch(ctx, expiresAt, &pkt.SignalPush{
+		Type:   req.Type,
+		Body:   req.Body,
+		Sender: ctx.Session().GetAccount(),
+	}, locs)
+}
+
+func (h *SignalHandler) DoGroupSignal(ctx HopeIM.Context) {
+	if ctx.Header().GetDest() == "" {
+		_ = ctx.RespWithError(pkt.Status_NoDestination, ErrNoDestination)
+		return
+	}
+	req, ok := h.readSignal(ctx)
+	if !ok {
+		return
+	}
+	expiresAt := h.expiresAt(ctx, req.Type)
+
+	group := ctx.Header().GetDest()
+	members, ok, err := h.members.Members(ctx, ctx.Session().GetApp(), group, ctx.Session().GetAccount())
+	if err != nil {
+		_ = ctx.RespWithError(pkt.Status_SystemException, err)
+		return
+	}
+	if !ok {
+		_ = ctx.RespWithError(pkt.Status_Unauthorized, ErrNotGroupMember)
+		return
+	}
+	locs, err := ctx.GetLocations(members...)
+	if err == HopeIM.ErrSessionNil {
+		return
+	} else if err != nil {
+		_ = ctx.RespWithError(pkt.Status_SystemException, err)
+		return
+	}
+	h.dispatch(ctx, expiresAt, &pkt.SignalPush{
+		Type:   req.Type,
+		Body:   req.Body,
+		Sender: ctx.Session().GetAccount(),
+		Group:  group,
+	}, locs)
+}
+
+func (h *SignalHandler) readSignal(ctx HopeIM.Context) (*pkt.SignalReq, bool) {
+	var req pkt.SignalReq
+	if err := ctx.ReadBody(&req); err != nil {
+		_ = ctx.RespWithError(pkt.Status_InvalidPacketBody, err)
+		return nil, false
+	}
+	if req.Type == "" {
+		_ = ctx.RespWithError(pkt.Status_InvalidPacketBody, ErrEmptySignalType)
+		return nil, false
+	}
+	if len(req.Body) > wire.SignalMaxBodySize {
+		_ = ctx.RespWithError(pkt.Status_InvalidPacketBody, fmt.Errorf("signal body is too large, max %d", wire.SignalMaxBodySize))
+		return nil, false
+	}
+	return &req, true
+}
+
+// expiresAt 有效期从网关收到信令时开始计算，包括在网关与逻辑服务之间排队的时间
+func (h *SignalHandler) expiresAt(ctx HopeIM.Context, typ string) time.Time {
+	now := time.Now()
+	start := now
+	for _, m := range ctx.Header().GetMeta() {
+		if m.Key != wire.MetaRecvTime {
+			continue
+		}
+		if ns, err := strconv.ParseInt(m.Value, 10, 64); err == nil && ns > 0 && ns < now.UnixNano() {
+			start = time.Unix(0, ns)
+		}
+		break
+	}
+	return start.Add(h.ttls.Of(typ))
+}
+
+// dispatch 超过有效期的信令直接丢弃，网关与接收方同样按照ExpiresAt丢弃迟到的信令
+func (h *SignalHandler) dispatch(ctx HopeIM.Context, expiresAt time.Time, push *pkt.SignalPush, locs []*HopeIM.Location) {
+	if time.Now().After(expiresAt) {
+		logger.WithField("module", "SignalHandler").Debugf("signal %s from %s expired", push.Type, push.Sender)
+		return
+	}
+	push.ExpiresAt = expiresAt.UnixNano()
+	metas := []*pkt.Meta{{
+		Key:   wire.MetaExpiresAt,
+		Value: strconv.FormatInt(push.ExpiresAt, 10),
+		Type:  pkt.MetaType_string,
+	}}
+	if err := ctx.DispatchWithMeta(metas, push, locs...); err != nil {
+		logger.WithField("module", "SignalHandler").Debugf("dispatch signal %s from %s: %v", push.Type, push.Sender, err)
+	}
+}
@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sjmshsh/HopeIM"
+	"github.com/sjmshsh/HopeIM/services/server/service"
+	"github.com/sjmshsh/HopeIM/storage"
+	"github.com/sjmshsh/HopeIM/wire"
+	"github.com/sjmshsh/HopeIM/wire/pkt"
+	"github.com/sjmshsh/HopeIM/wire/rpc"
+	"github.com/stretchr/testify/assert"
+)
+
+// testGroup 只实现Members，记录调用次数
+type testGroup struct {
+	service.Group
+	sync.Mutex
+	members []string
+	calls   int
+}
+
+func (g *testGroup) Members(ctx context.Context, app string, req *rpc.GroupMembersReq) (*rpc.GroupMembersResp, error) {
+	g.Lock()
+	defer g.Unlock()
+	g.calls++
+	resp := &rpc.GroupMembersResp{}
+	for _, account := range g.members {
+		resp.Users = append(resp.Users, &rpc.Member{Account: account})
+	}
+	return resp, nil
+}
+
+// writeStorage 记录对会话的写操作
+type writeStorage struct {
+	HopeIM.SessionStorage
+	writes int
+}
+
+func (s *writeStorage) Add(session *pkt.Session) error {
+	s.writes++
+	return s.SessionStorage.Add(session)
+}
+
+func (s *writeStorage) Delete(account string, channelId string) error {
+	s.writes++
+	return s.SessionStorage.Delete(account, channelId)
+}
+
+func newSignalRouter(group service.Group) *HopeIM.Router {
+	h := NewSignalHandler(group, HopeIM.SignalTTLs{Default: time.Second * 5})
+	r := HopeIM.NewRouter()
+	r.Handle(wire.CommandChatUserSignal, h.DoUserSignal)
+	r.Handle(wire.CommandChatGroupSignal, h.DoGroupSignal)
+	return r
+}
+
+func signal(command, dest string, recvTime time.Time) *pkt.LogicPkt {
+	p := pkt.New(command, pkt.WithChannel("ch1"), pkt.WithDest(dest))
+	p.AddStringMeta(wire.MetaRecvTime, strconv.FormatInt(recvTime.UnixNano(), 10))
+	p.WriteBody(&pkt.SignalReq{Type: wire.SignalTyping, Body: "typing"})
+	return p
+}
+
+func TestSignalHandler_UserSignal(t *testing.T) {
+	r := newSignalRouter(&testGroup{})
+	cache := storage.NewMemoryStorage(0)
+	assert.Nil(t, cache.Add(&pkt.Session{ChannelId: "ch1", GateId: "gate1", Account: "test1"}))
+	store := &writeStorage{SessionStorage: cache}
+	session, _ := cache.Get("ch1")
+
+	// 接收方不在线，信令直接丢弃，不保存
+	d := &testDispather{}
+	_ = r.Serve(signal(wire.CommandChatUserSignal, "test2", time.Now()), d, store, session)
+	assert.Empty(t, d.pushes)
+
+	// 接收方上线之后也收不到之前的信令
+	assert.Nil(t, cache.Add(&pkt.Session{ChannelId: "ch2", GateId: "gate1", Account: "test2"}))
+	assert.Empty(t, d.pushes)
+
+	_ = r.Serve(signal(wire.CommandChatUserSignal, "test2", time.Now()), d, store, session)
+	pushes := d.commands(wire.CommandChatUserSignal)
+	assert.Len(t, pushes, 1)
+	assert.Equal(t, []string{"ch2"}, pushes[0].channels)
+	// 网关按照MetaExpiresAt丢弃过期的推送
+	_, ok := pushes[0].packet.GetMeta(wire.MetaExpiresAt)
+	assert.True(t, ok)
+	var push pkt.SignalPush
+	assert.Nil(t, pushes[0].packet.ReadBody(&push))
+	assert.Equal(t, "test1", push.Sender)
+	// 推送成功时不返回resp，也没有写入任何数据
+	assert.Len(t, d.pushes, 1)
+	assert.Equal(t, 0, store.writes)
+}
+
+func TestSignalHandler_Expired(t *testing.T) {
+	r := newSignalRouter(&testGroup{members: []string{"test1", "test2"}})
+	cache := storage.NewMemoryStorage(0)
+	assert.Nil(t, cache.Add(&pkt.Session{ChannelId: "ch1", GateId: "gate1", Account: "test1"}))
+	assert.Nil(t, cache.Add(&pkt.Session{ChannelId: "ch2", GateId: "gate1", Account: "test2"}))
+	session, _ := cache.Get("ch1")
+
+	// 有效期从网关收到信令时开始计算
+	d := &testDispather{}
+	_ = r.Serve(signal(wire.CommandChatUserSignal, "test2", time.Now().Add(-time.Second*6)), d, cache, session)
+	_ = r.Serve(signal(wire.CommandChatGroupSignal, "group1", time.Now().Add(-time.Second*6)), d, cache, session)
+	assert.Empty(t, d.pushes)
+
+	_ = r.Serve(signal(wire.CommandChatUserSignal, "test2", time.Now().Add(-time.Second*4)), d, cache, session)
+	assert.Len(t, d.commands(wire.CommandChatUserSignal), 1)
+}
+
+func TestSignalHandler_GroupSignal(t *testing.T) {
+	group := &testGroup{members: []string{"test1", "test2"}}
+	r := newSignalRouter(group)
+	cache := storage.NewMemoryStorage(0)
+	assert.Nil(t, cache.Add(&pkt.Session{ChannelId: "ch1", GateId: "gate1", Account: "test1"}))
+	assert.Nil(t, cache.Add(&pkt.Session{ChannelId: "ch2", GateId: "gate1", Account: "test2"}))
+	assert.Nil(t, cache.Add(&pkt.Session{ChannelId: "ch3", GateId: "gate1", Account: "test3"}))
+
+	d := &testDispather{}
+	session, _ := cache.Get("ch1")
+	_ = r.Serve(signal(wire.CommandChatGroupSignal, "group1", time.Now()), d, cache, session)
+	_ = r.Serve(signal(wire.CommandChatGroupSignal, "group1", time.Now()), d, cache, session)
+	pushes := d.commands(wire.CommandChatGroupSignal)
+	assert.Len(t, pushes, 2)
+	// 发送方自己不推送
+	assert.Equal(t, []string{"ch2"}, pushes[0].channels)
+	// 成员列表被缓存
+	assert.Equal(t, 1, group.calls)
+
+	// 不是群成员不能发送
+	d = &testDispather{}
+	session, _ = cache.Get("ch3")
+	_ = r.Serve(signal(wire.CommandChatGroupSignal, "group1", time.Now()), d, cache, session)
+	assert.Len(t, d.pushes, 1)
+	assert.Equal(t, pkt.Flag_Response, d.pushes[0].packet.Flag)
+	assert.Equal(t, pkt.Status_Unauthorized, d.pushes[0].packet.Status)
+}
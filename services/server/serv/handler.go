@@ -2,6 +2,7 @@ package serv
 
 import (
 	"bytes"
+	"context"
 	"github.com/golang/protobuf/proto"
 	"github.com/sjmshsh/HopeIM"
 	"github.com/sjmshsh/HopeIM/container"
@@ -31,20 +32,21 @@ func NewServHandler(r *HopeIM.Router, cache HopeIM.SessionStorage) *ServHanlder
 	}
 }
 
-func (h *ServHanlder) Accept(conn HopeIM.Conn, timeout time.Duration) (string, error) {
+func (h *ServHanlder) Accept(conn HopeIM.Conn, timeout time.Duration) (string, *HopeIM.Attributes, error) {
 	log.Infoln("enter")
 
 	_ = conn.SetReadDeadline(time.Now().Add(timeout))
 	frame, err := conn.ReadFrame()
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 
 	var req pkt.InnerHandshakeReq
 	_ = proto.Unmarshal(frame.GetPayload(), &req)
+	HopeIM.ReleaseFrame(frame)
 	log.Info("Accept -- ", req.ServiceId)
 
-	return req.ServiceId, nil
+	return req.ServiceId, nil, nil
 }
 
 func (h *ServHanlder) Receive(ag HopeIM.Agent, payload []byte) {
@@ -55,14 +57,27 @@ func (h *ServHanlder) Receive(ag HopeIM.Agent, payload []byte) {
 		return
 	}
 	var session *pkt.Session
-	if packet.Command == wire.CommandLoginSignIn {
+	switch packet.Command {
+	case wire.CommandLoginSignIn:
+		// 登录请求不属于某一个已有的会话
 		server, _ := packet.GetMeta(wire.MetaDestServer)
 		session = &pkt.Session{
 			ChannelId: packet.ChannelId,
 			GateId:    server.(string),
 			Tags:      []string{"AutoGenerated"},
 		}
-	} else {
+	case wire.CommandLoginTouch:
+		// 会话续期只接受网关自己发出的请求，ag是网关的连接，ID为网关的ServiceID
+		gateway, _ := packet.GetMeta(wire.MetaFromGateway)
+		if gateway != ag.ID() {
+			log.Warnf("drop %s not from gateway %s", packet.Command, ag.ID())
+			return
+		}
+		session = &pkt.Session{
+			GateId: ag.ID(),
+			Tags:   []string{"Gateway"},
+		}
+	default:
 		// TODO 优化点
 		session, err = h.cache.Get(packet.ChannelId)
 		if err == HopeIM.ErrSessionNil {
@@ -90,13 +105,16 @@ func RespErr(ag HopeIM.Agent, p *pkt.LogicPkt, status pkt.Status) error {
 
 type ServerDispather struct{}
 
-func (d *ServerDispather) Push(gateway string, channels []string, p *pkt.LogicPkt) error {
+func (d *ServerDispather) Push(ctx context.Context, gateway string, channels []string, p *pkt.LogicPkt) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	p.AddStringMeta(wire.MetaDestChannels, strings.Join(channels, ","))
 	return container.Push(gateway, p)
 }
 
 // Disconnect default listener
-func (h *ServHanlder) Disconnect(id string) error {
-	logger.Warnf("close event of %s", id)
+func (h *ServHanlder) Disconnect(ag HopeIM.Agent) error {
+	logger.Warnf("close event of %s", ag.ID())
 	return nil
 }
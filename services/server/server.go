@@ -2,6 +2,7 @@ package server
 
 import (
 	"context"
+	"fmt"
 	"github.com/sjmshsh/HopeIM"
 	"github.com/sjmshsh/HopeIM/container"
 	"github.com/sjmshsh/HopeIM/logger"
@@ -10,6 +11,7 @@ import (
 	"github.com/sjmshsh/HopeIM/services/server/conf"
 	"github.com/sjmshsh/HopeIM/services/server/handler"
 	"github.com/sjmshsh/HopeIM/services/server/serv"
+	"github.com/sjmshsh/HopeIM/services/server/service"
 	"github.com/sjmshsh/HopeIM/storage"
 	"github.com/sjmshsh/HopeIM/tcp"
 	"github.com/sjmshsh/HopeIM/wire"
@@ -45,17 +47,61 @@ func RunServerStart(ctx context.Context, opts *ServerStartOptions, version strin
 		Level: "trace",
 	})
 
+	rdb, err := conf.InitRedis(config.RedisAddrs, "")
+	if err != nil {
+		return err
+	}
+	cache := storage.NewRedisStorage(rdb)
+
 	r := HopeIM.NewRouter()
+	r.SetTimeout(config.RequestTimeout)
 	// login
-	loginHandler := handler.NewLoginHandler()
+	delivery := HopeIM.NewDelivery(&serv.ServerDispather{}, storage.NewRedisDeliveryStore(rdb), config.Delivery)
+	defer delivery.Close()
+	presenceHandler := handler.NewPresenceHandler(storage.NewRedisPresenceStorage(rdb))
+	loginHandler := handler.NewLoginHandler(config.DevicePolicies, presenceHandler, delivery)
 	r.Handle(wire.CommandLoginSignIn, loginHandler.DoSysLogin)
 	r.Handle(wire.CommandLoginSignOut, loginHandler.DoSysLogout)
+	r.Handle(wire.CommandLoginTouch, loginHandler.DoSysTouch)
+	// chat
+	messageService := service.NewMessageService(config.RpcURL)
+	deduper := handler.NewMessageDeduper(config.DedupWindow)
+	groupService := service.NewGroupService(config.RpcURL)
+	chatHandler := handler.NewChatHandler(messageService, groupService, delivery, deduper)
+	r.Handle(wire.CommandChatUserTalk, chatHandler.DoUserTalk)
+	r.Handle(wire.CommandChatGroupTalk, chatHandler.DoGroupTalk)
+	r.Handle(wire.CommandChatTalkAck, chatHandler.DoTalkAck)
+	// signal
+	signalHandler := handler.NewSignalHandler(groupService, config.SignalTTL)
+	r.Handle(wire.CommandChatUserSignal, signalHandler.DoUserSignal)
+	r.Handle(wire.CommandChatGroupSignal, signalHandler.DoGroupSignal)
+	// presence
+	r.Handle(wire.CommandPresenceQuery, presenceHandler.DoQuery)
+	r.Handle(wire.CommandPresenceSubscribe, presenceHandler.DoSubscribe)
+	r.Handle(wire.CommandPresenceSet, presenceHandler.DoSetStatus)
+	// offline
+	offlineHandler := handler.NewOfflineHandler(messageService)
+	r.Handle(wire.CommandOfflineIndex, offlineHandler.DoSyncIndex)
+	r.Handle(wire.CommandOfflineContent, offlineHandler.DoSyncContent)
 
-	rdb, err := conf.InitRedis(config.RedisAddrs, "")
-	if err != nil {
-		return err
+	var limiter HopeIM.RateLimiter
+	limits := config.RateLimit.Options()
+	switch config.RateLimit.Backend {
+	case "memory":
+		limiter = HopeIM.NewMemoryRateLimiter(HopeIM.DefaultRateLimitIdle)
+	case "redis":
+		limiter = storage.NewRedisRateLimiter(rdb)
+	case "":
+		// 不限流时信令仍然按照单个节点限流
+		limiter = HopeIM.NewMemoryRateLimiter(HopeIM.DefaultRateLimitIdle)
+		limits = HopeIM.RateLimitOptions{Commands: make(map[string]HopeIM.RateLimit)}
+		for command, limit := range HopeIM.DefaultSignalRateLimits {
+			limits.Commands[command] = limit
+		}
+	default:
+		return fmt.Errorf("unknown rate limit backend %s", config.RateLimit.Backend)
 	}
-	cache := storage.NewRedisStorage(rdb)
+	r.Use(HopeIM.RateLimitMiddleware(limiter, limits))
 	servHandler := serv.NewServHandler(r, cache)
 
 	service := &naming.DefaultService{
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"fmt"
 	"github.com/sjmshsh/HopeIM/logger"
 	"github.com/sjmshsh/HopeIM/wire/rpc"
@@ -12,11 +13,11 @@ import (
 )
 
 type Group interface {
-	Create(app string, req *rpc.CreateGroupReq) (*rpc.CreateGroupResp, error)
-	Members(app string, req *rpc.GroupMembersReq) (*rpc.GroupMembersResp, error)
-	Join(app string, req *rpc.JoinGroupReq) error
-	Quit(app string, req *rpc.QuitGroupReq) error
-	Detail(app string, req *rpc.GetGroupReq) (*rpc.GetGroupResp, error)
+	Create(ctx context.Context, app string, req *rpc.CreateGroupReq) (*rpc.CreateGroupResp, error)
+	Members(ctx context.Context, app string, req *rpc.GroupMembersReq) (*rpc.GroupMembersResp, error)
+	Join(ctx context.Context, app string, req *rpc.JoinGroupReq) error
+	Quit(ctx context.Context, app string, req *rpc.QuitGroupReq) error
+	Detail(ctx context.Context, app string, req *rpc.GetGroupReq) (*rpc.GetGroupResp, error)
 }
 
 type GroupHttp struct {
@@ -49,11 +50,11 @@ func NewGroupServiceWithSRV(scheme string, srv *resty.SRVRecord) Group {
 	}
 }
 
-func (g *GroupHttp) Create(app string, req *rpc.CreateGroupReq) (*rpc.CreateGroupResp, error) {
+func (g *GroupHttp) Create(ctx context.Context, app string, req *rpc.CreateGroupReq) (*rpc.CreateGroupResp, error) {
 	path := fmt.Sprintf("%s/api/%s/group", g.url, app)
 
 	body, _ := proto.Marshal(req)
-	response, err := g.Req().SetBody(body).Post(path)
+	response, err := g.Req(ctx).SetBody(body).Post(path)
 	if err != nil {
 		return nil, err
 	}
@@ -66,10 +67,10 @@ func (g *GroupHttp) Create(app string, req *rpc.CreateGroupReq) (*rpc.CreateGrou
 	return &resp, nil
 }
 
-func (g *GroupHttp) Members(app string, req *rpc.GroupMembersReq) (*rpc.GroupMembersResp, error) {
+func (g *GroupHttp) Members(ctx context.Context, app string, req *rpc.GroupMembersReq) (*rpc.GroupMembersResp, error) {
 	path := fmt.Sprintf("%s/api/%s/group/members/%s", g.url, app, req.GroupId)
 
-	response, err := g.Req().Get(path)
+	response, err := g.Req(ctx).Get(path)
 	if err != nil {
 		return nil, err
 	}
@@ -82,10 +83,10 @@ func (g *GroupHttp) Members(app string, req *rpc.GroupMembersReq) (*rpc.GroupMem
 	return &resp, nil
 }
 
-func (g *GroupHttp) Join(app string, req *rpc.JoinGroupReq) error {
+func (g *GroupHttp) Join(ctx context.Context, app string, req *rpc.JoinGroupReq) error {
 	path := fmt.Sprintf("%s/api/%s/group/member", g.url, app)
 	body, _ := proto.Marshal(req)
-	response, err := g.Req().SetBody(body).Post(path)
+	response, err := g.Req(ctx).SetBody(body).Post(path)
 	if err != nil {
 		return err
 	}
@@ -95,10 +96,10 @@ func (g *GroupHttp) Join(app string, req *rpc.JoinGroupReq) error {
 	return nil
 }
 
-func (g *GroupHttp) Quit(app string, req *rpc.QuitGroupReq) error {
+func (g *GroupHttp) Quit(ctx context.Context, app string, req *rpc.QuitGroupReq) error {
 	path := fmt.Sprintf("%s/api/%s/group/member", g.url, app)
 	body, _ := proto.Marshal(req)
-	response, err := g.Req().SetBody(body).Delete(path)
+	response, err := g.Req(ctx).SetBody(body).Delete(path)
 	if err != nil {
 		return err
 	}
@@ -108,9 +109,9 @@ func (g *GroupHttp) Quit(app string, req *rpc.QuitGroupReq) error {
 	return nil
 }
 
-func (g *GroupHttp) Detail(app string, req *rpc.GetGroupReq) (*rpc.GetGroupResp, error) {
+func (g *GroupHttp) Detail(ctx context.Context, app string, req *rpc.GetGroupReq) (*rpc.GetGroupResp, error) {
 	path := fmt.Sprintf("%s/api/%s/group/%s", g.url, app, req.GroupId)
-	response, err := g.Req().Get(path)
+	response, err := g.Req(ctx).Get(path)
 	if err != nil {
 		return nil, err
 	}
@@ -123,9 +124,9 @@ func (g *GroupHttp) Detail(app string, req *rpc.GetGroupReq) (*rpc.GetGroupResp,
 	return &resp, nil
 }
 
-func (g *GroupHttp) Req() *resty.Request {
+func (g *GroupHttp) Req(ctx context.Context) *resty.Request {
 	if g.srv == nil {
-		return g.cli.R()
+		return g.cli.R().SetContext(ctx)
 	}
-	return g.cli.R().SetSRV(g.srv)
+	return g.cli.R().SetContext(ctx).SetSRV(g.srv)
 }
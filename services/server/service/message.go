@@ -1,20 +1,22 @@
 package service
 
 import (
+	"context"
 	"fmt"
 	"github.com/go-resty/resty/v2"
 	"github.com/golang/protobuf/proto"
 	"github.com/sjmshsh/HopeIM/logger"
 	"github.com/sjmshsh/HopeIM/wire/rpc"
+	"net/http"
 	"time"
 )
 
 type Message interface {
-	InsertUser(app string, req *rpc.InsertMessageReq) (*rpc.InsertMessageResp, error)
-	InsertGroup(app string, req *rpc.InsertMessageReq) (*rpc.InsertMessageResp, error)
-	SetAck(app string, req *rpc.AckMessageReq) error
-	GetMessageIndex(app string, req *rpc.GetOfflineMessageIndexReq) (*rpc.GetOfflineMessageIndexResp, error)
-	GetMessageContent(app string, req *rpc.GetOfflineMessageContentReq) (*rpc.GetOfflineMessageContentResp, error)
+	InsertUser(ctx context.Context, app string, req *rpc.InsertMessageReq) (*rpc.InsertMessageResp, error)
+	InsertGroup(ctx context.Context, app string, req *rpc.InsertMessageReq) (*rpc.InsertMessageResp, error)
+	SetAck(ctx context.Context, app string, req *rpc.AckMessageReq) error
+	GetMessageIndex(ctx context.Context, app string, req *rpc.GetOfflineMessageIndexReq) (*rpc.GetOfflineMessageIndexResp, error)
+	GetMessageContent(ctx context.Context, app string, req *rpc.GetOfflineMessageContentReq) (*rpc.GetOfflineMessageContentResp, error)
 }
 
 type MessageHttp struct {
@@ -23,8 +25,13 @@ type MessageHttp struct {
 	srv *resty.SRVRecord
 }
 
+// retryInserting 同一个客户端消息ID的第一次请求还在保存时，消息服务返回409，稍后重试就可以拿到第一次的结果
+func retryInserting(r *resty.Response, err error) bool {
+	return err == nil && r.StatusCode() == http.StatusConflict
+}
+
 func NewMessageService(url string) Message {
-	cli := resty.New().SetRetryCount(3).SetTimeout(time.Second * 5)
+	cli := resty.New().SetRetryCount(3).SetTimeout(time.Second * 5).AddRetryCondition(retryInserting)
 	cli.SetHeader("Content-Type", "application/x-protobuf")
 	cli.SetHeader("Accept", "application/x-protobuf")
 	return &MessageHttp{
@@ -34,7 +41,7 @@ func NewMessageService(url string) Message {
 }
 
 func NewMessageServiceWithSRV(scheme string, srv *resty.SRVRecord) Message {
-	cli := resty.New().SetRetryCount(3).SetTimeout(time.Second * 5)
+	cli := resty.New().SetRetryCount(3).SetTimeout(time.Second * 5).AddRetryCondition(retryInserting)
 	cli.SetHeader("Content-Type", "application/x-protobuf")
 	cli.SetHeader("Accept", "application/x-protobuf")
 	cli.SetScheme("http")
@@ -46,12 +53,12 @@ func NewMessageServiceWithSRV(scheme string, srv *resty.SRVRecord) Message {
 	}
 }
 
-func (m *MessageHttp) InsertUser(app string, req *rpc.InsertMessageReq) (*rpc.InsertMessageResp, error) {
+func (m *MessageHttp) InsertUser(ctx context.Context, app string, req *rpc.InsertMessageReq) (*rpc.InsertMessageResp, error) {
 	path := fmt.Sprintf("%s/api/%s/message/user", m.url, app)
 	t1 := time.Now()
 
 	body, _ := proto.Marshal(req)
-	response, err := m.Req().SetBody(body).Post(path)
+	response, err := m.Req(ctx).SetBody(body).Post(path)
 	if err != nil {
 		return nil, err
 	}
@@ -64,11 +71,11 @@ func (m *MessageHttp) InsertUser(app string, req *rpc.InsertMessageReq) (*rpc.In
 	return &resp, nil
 }
 
-func (m *MessageHttp) InsertGroup(app string, req *rpc.InsertMessageReq) (*rpc.InsertMessageResp, error) {
+func (m *MessageHttp) InsertGroup(ctx context.Context, app string, req *rpc.InsertMessageReq) (*rpc.InsertMessageResp, error) {
 	path := fmt.Sprintf("%s/api/%s/message/group", m.url, app)
 	t1 := time.Now()
 	body, _ := proto.Marshal(req)
-	response, err := m.Req().SetBody(body).Post(path)
+	response, err := m.Req(ctx).SetBody(body).Post(path)
 	if err != nil {
 		return nil, err
 	}
@@ -81,10 +88,10 @@ func (m *MessageHttp) InsertGroup(app string, req *rpc.InsertMessageReq) (*rpc.I
 	return &resp, nil
 }
 
-func (m *MessageHttp) SetAck(app string, req *rpc.AckMessageReq) error {
+func (m *MessageHttp) SetAck(ctx context.Context, app string, req *rpc.AckMessageReq) error {
 	path := fmt.Sprintf("%s/api/%s/message/ack", m.url, app)
 	body, _ := proto.Marshal(req)
-	response, err := m.Req().SetBody(body).Post(path)
+	response, err := m.Req(ctx).SetBody(body).Post(path)
 	if err != nil {
 		return err
 	}
@@ -94,11 +101,11 @@ func (m *MessageHttp) SetAck(app string, req *rpc.AckMessageReq) error {
 	return nil
 }
 
-func (m *MessageHttp) GetMessageIndex(app string, req *rpc.GetOfflineMessageIndexReq) (*rpc.GetOfflineMessageIndexResp, error) {
+func (m *MessageHttp) GetMessageIndex(ctx context.Context, app string, req *rpc.GetOfflineMessageIndexReq) (*rpc.GetOfflineMessageIndexResp, error) {
 	path := fmt.Sprintf("%s/api/%s/offline/index", m.url, app)
 	body, _ := proto.Marshal(req)
 
-	response, err := m.Req().SetBody(body).Post(path)
+	response, err := m.Req(ctx).SetBody(body).Post(path)
 	if err != nil {
 		return nil, err
 	}
@@ -110,10 +117,10 @@ func (m *MessageHttp) GetMessageIndex(app string, req *rpc.GetOfflineMessageInde
 	return &resp, nil
 }
 
-func (m *MessageHttp) GetMessageContent(app string, req *rpc.GetOfflineMessageContentReq) (*rpc.GetOfflineMessageContentResp, error) {
+func (m *MessageHttp) GetMessageContent(ctx context.Context, app string, req *rpc.GetOfflineMessageContentReq) (*rpc.GetOfflineMessageContentResp, error) {
 	path := fmt.Sprintf("%s/api/%s/offline/content", m.url, app)
 	body, _ := proto.Marshal(req)
-	response, err := m.Req().SetBody(body).Post(path)
+	response, err := m.Req(ctx).SetBody(body).Post(path)
 	if err != nil {
 		return nil, err
 	}
@@ -125,9 +132,9 @@ func (m *MessageHttp) GetMessageContent(app string, req *rpc.GetOfflineMessageCo
 	return &resp, nil
 }
 
-func (m *MessageHttp) Req() *resty.Request {
+func (m *MessageHttp) Req(ctx context.Context) *resty.Request {
 	if m.srv == nil {
-		return m.cli.R()
+		return m.cli.R().SetContext(ctx)
 	}
-	return m.cli.R().SetSRV(m.srv)
+	return m.cli.R().SetContext(ctx).SetSRV(m.srv)
 }
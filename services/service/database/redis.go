@@ -14,6 +14,12 @@ func KeyMessageAckIndex(account string) string {
 	return fmt.Sprintf("chat:ack:%s", account)
 }
 
+// KeyMessageDedup return a redis key of the client message id sent by the account,
+// kind is user or group and dest is the receiver or the group
+func KeyMessageDedup(app, kind, account, dest, clientMsgId string) string {
+	return fmt.Sprintf("chat:dedup:%s:%s:%s:%s:%s", app, kind, account, dest, clientMsgId)
+}
+
 // InitRedis return a redis instance
 func InitRedis(addr string, pass string) (*redis.Client, error) {
 	redisdb := redis.NewClient(&redis.Options{
@@ -1,8 +1,12 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
+
 	"github.com/go-redis/redis/v7"
 	"github.com/kataras/iris/v12"
+	"github.com/sjmshsh/HopeIM/logger"
 	"github.com/sjmshsh/HopeIM/services/service/database"
 	"github.com/sjmshsh/HopeIM/wire"
 	"github.com/sjmshsh/HopeIM/wire/rpc"
@@ -10,6 +14,12 @@ import (
 	"time"
 )
 
+// ErrMessageInserting 同一个客户端消息ID的第一次请求还没有保存完成，调用方可以稍后重试
+var ErrMessageInserting = errors.New("err:message with the same client message id is being inserted")
+
+// dedupPending 保存完成之前的去重记录
+const dedupPending = "pending"
+
 type ServiceHandler struct {
 	BaseDb    *gorm.DB
 	MessageDb *gorm.DB
@@ -24,18 +34,70 @@ func (h *ServiceHandler) InsertUserMessage(c iris.Context) {
 		return
 	}
 
-	messageId, err := h.insertUserMessage(&req)
+	resp, err := h.dedupInsert(c.Params().Get("app"), "user", &req, h.insertUserMessage)
+	if err == ErrMessageInserting {
+		c.StopWithError(iris.StatusConflict, err)
+		return
+	}
 	if err != nil {
 		c.StopWithError(iris.StatusInternalServerError, err)
 		return
 	}
-	_, _ = c.Negotiate(&rpc.InsertMessageResp{
-		MessageId: messageId,
-	})
+	_, _ = c.Negotiate(resp)
 }
 
-func (h *ServiceHandler) insertUserMessage(req *rpc.InsertMessageReq) (int64, error) {
+// dedupInsert 按照app、消息类型kind、发送方、接收方与客户端消息ID去重，窗口内重复的请求返回第一次保存的消息ID与发送时间。
+// 保存之前先写入pending，保存成功之后才写入结果，第一次请求还在保存时重复的请求返回ErrMessageInserting
+func (h *ServiceHandler) dedupInsert(app, kind string, req *rpc.InsertMessageReq, insert func(req *rpc.InsertMessageReq, messageId int64) error) (*rpc.InsertMessageResp, error) {
 	messageId := h.Idgen.Next().Int64()
+	if req.ClientMsgId == "" {
+		if err := insert(req, messageId); err != nil {
+			return nil, err
+		}
+		return &rpc.InsertMessageResp{
+			MessageId: messageId,
+			SendTime:  req.SendTime,
+		}, nil
+	}
+	key := database.KeyMessageDedup(app, kind, req.Sender, req.Dest, req.ClientMsgId)
+	ok, err := h.Cache.SetNX(key, dedupPending, wire.MessageDedupPendingIn).Result()
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		val, err := h.Cache.Get(key).Result()
+		if err == redis.Nil {
+			// 第一次请求保存失败，记录已经被删除
+			return nil, ErrMessageInserting
+		}
+		if err != nil {
+			return nil, err
+		}
+		if val == dedupPending {
+			return nil, ErrMessageInserting
+		}
+		resp := rpc.InsertMessageResp{Duplicate: true}
+		if _, err = fmt.Sscanf(val, "%d:%d", &resp.MessageId, &resp.SendTime); err != nil {
+			return nil, err
+		}
+		return &resp, nil
+	}
+	if err = insert(req, messageId); err != nil {
+		// 保存失败时删除记录，客户端可以使用同一个ID重试
+		_ = h.Cache.Del(key).Err()
+		return nil, err
+	}
+	err = h.Cache.Set(key, fmt.Sprintf("%d:%d", messageId, req.SendTime), wire.MessageDedupExpiresIn).Err()
+	if err != nil {
+		logger.Warnf("set dedup of message %d failed: %v", messageId, err)
+	}
+	return &rpc.InsertMessageResp{
+		MessageId: messageId,
+		SendTime:  req.SendTime,
+	}, nil
+}
+
+func (h *ServiceHandler) insertUserMessage(req *rpc.InsertMessageReq, messageId int64) error {
 	messageContent := database.MessageContent{
 		ID:       messageId,
 		Type:     byte(req.Message.Type),
@@ -62,7 +124,7 @@ func (h *ServiceHandler) insertUserMessage(req *rpc.InsertMessageReq) (int64, er
 		SendTime:  req.SendTime,
 	}
 
-	err := h.MessageDb.Transaction(func(tx *gorm.DB) error {
+	return h.MessageDb.Transaction(func(tx *gorm.DB) error {
 		if err := tx.Create(&messageContent).Error; err != nil {
 			return err
 		}
@@ -71,10 +133,6 @@ func (h *ServiceHandler) insertUserMessage(req *rpc.InsertMessageReq) (int64, er
 		}
 		return nil
 	})
-	if err != nil {
-		return 0, err
-	}
-	return messageId, nil
 }
 
 func (h *ServiceHandler) InsertGroupMessage(c iris.Context) {
@@ -83,23 +141,23 @@ func (h *ServiceHandler) InsertGroupMessage(c iris.Context) {
 		c.StopWithError(iris.StatusBadRequest, err)
 		return
 	}
-	messageId, err := h.insertGroupMessage(&req)
+	resp, err := h.dedupInsert(c.Params().Get("app"), "group", &req, h.insertGroupMessage)
+	if err == ErrMessageInserting {
+		c.StopWithError(iris.StatusConflict, err)
+		return
+	}
 	if err != nil {
 		c.StopWithError(iris.StatusInternalServerError, err)
 		return
 	}
-	_, _ = c.Negotiate(&rpc.InsertMessageResp{
-		MessageId: messageId,
-	})
+	_, _ = c.Negotiate(resp)
 }
 
-func (h *ServiceHandler) insertGroupMessage(req *rpc.InsertMessageReq) (int64, error) {
-	messageId := h.Idgen.Next().Int64()
-
+func (h *ServiceHandler) insertGroupMessage(req *rpc.InsertMessageReq, messageId int64) error {
 	var members []database.GroupMember
 	err := h.BaseDb.Where(&database.GroupMember{Group: req.Dest}).Find(&members).Error
 	if err != nil {
-		return 0, err
+		return err
 	}
 	// 扩散写
 	var idxs = make([]database.MessageIndex, len(members))
@@ -126,7 +184,7 @@ func (h *ServiceHandler) insertGroupMessage(req *rpc.InsertMessageReq) (int64, e
 		SendTime: req.SendTime,
 	}
 
-	err = h.MessageDb.Transaction(func(tx *gorm.DB) error {
+	return h.MessageDb.Transaction(func(tx *gorm.DB) error {
 		if err := tx.Create(&messageContent).Error; err != nil {
 			return err
 		}
@@ -135,10 +193,6 @@ func (h *ServiceHandler) insertGroupMessage(req *rpc.InsertMessageReq) (int64, e
 		}
 		return nil
 	})
-	if err != nil {
-		return 0, err
-	}
-	return messageId, nil
 }
 
 func (h *ServiceHandler) MessageAck(c iris.Context) {
@@ -0,0 +1,34 @@
+package HopeIM
+
+import (
+	"time"
+
+	"github.com/sjmshsh/HopeIM/wire"
+)
+
+// DefaultSignalTTL 没有配置有效期的信令类型使用的默认值
+const DefaultSignalTTL = time.Second * 5
+
+// DefaultSignalRateLimits 信令不保存，发送方可以高频发送，没有配置限流时使用的默认值
+var DefaultSignalRateLimits = map[string]RateLimit{
+	wire.CommandChatUserSignal:  {Rate: 2, Burst: 5},
+	wire.CommandChatGroupSignal: {Rate: 1, Burst: 3},
+}
+
+// SignalTTLs 按照信令类型配置的有效期，超过有效期还没有推送出去的信令会被丢弃
+type SignalTTLs struct {
+	// Default 没有单独配置的类型使用的有效期，为0时使用DefaultSignalTTL
+	Default time.Duration
+	Types   map[string]time.Duration
+}
+
+// Of 返回信令类型的有效期
+func (s SignalTTLs) Of(typ string) time.Duration {
+	if ttl, ok := s.Types[typ]; ok && ttl > 0 {
+		return ttl
+	}
+	if s.Default <= 0 {
+		return DefaultSignalTTL
+	}
+	return s.Default
+}
@@ -0,0 +1,25 @@
+package HopeIM
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sjmshsh/HopeIM/wire"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignalTTLs_Of(t *testing.T) {
+	var ttls SignalTTLs
+	assert.Equal(t, DefaultSignalTTL, ttls.Of(wire.SignalTyping))
+
+	ttls = SignalTTLs{
+		Default: time.Second * 10,
+		Types: map[string]time.Duration{
+			wire.SignalTyping: time.Second * 3,
+			"call":            0,
+		},
+	}
+	assert.Equal(t, time.Second*3, ttls.Of(wire.SignalTyping))
+	assert.Equal(t, time.Second*10, ttls.Of("call"))
+	assert.Equal(t, time.Second*10, ttls.Of("unknown"))
+}
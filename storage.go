@@ -13,4 +13,7 @@ type SessionStorage interface {
 	Get(channelId string) (*pkt.Session, error)
 	GetLocations(account ...string) ([]*Location, error)
 	GetLocation(account string, device string) (*Location, error)
+	// Touch 刷新保存在网关gateId上的会话及其账号Location的过期时间，返回被刷新的会话。
+	// 已经过期、删除或者已经在其它网关上重新登录的会话会被跳过
+	Touch(gateId string, sessions ...*pkt.TouchSession) ([]*pkt.TouchSession, error)
 }
@@ -0,0 +1,164 @@
+package storage
+
+import (
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/sjmshsh/HopeIM"
+	"github.com/sjmshsh/HopeIM/wire/pkt"
+)
+
+// MemoryStorage 内存中的SessionStorage，适用于单节点部署与测试。
+// 过期规则与RedisStorage相同：会话各自过期，Location随着它的会话一起过期
+type MemoryStorage struct {
+	sync.Mutex
+	ttl      time.Duration
+	sessions map[string]*memorySession
+	// account -> channelId -> Location
+	locations map[string]map[string]HopeIM.Location
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+type memorySession struct {
+	session  *pkt.Session
+	expireAt time.Time
+}
+
+// NewMemoryStorage ttl为0时使用LocationExpired
+func NewMemoryStorage(ttl time.Duration) HopeIM.SessionStorage {
+	if ttl <= 0 {
+		ttl = LocationExpired
+	}
+	return &MemoryStorage{
+		ttl:       ttl,
+		sessions:  make(map[string]*memorySession),
+		locations: make(map[string]map[string]HopeIM.Location),
+		lastSweep: time.Now(),
+		now:       time.Now,
+	}
+}
+
+// Add 保存会话
+func (m *MemoryStorage) Add(session *pkt.Session) error {
+	m.Lock()
+	defer m.Unlock()
+	now := m.now()
+	m.sweep(now)
+	expireAt := now.Add(m.ttl)
+
+	locs, ok := m.locations[session.Account]
+	if !ok {
+		locs = make(map[string]HopeIM.Location)
+		m.locations[session.Account] = locs
+	}
+	locs[session.ChannelId] = HopeIM.Location{
+		ChannelId:   session.ChannelId,
+		GateId:      session.GateId,
+		ContentType: session.ContentType,
+		Device:      session.Device,
+	}
+
+	// 保存副本，调用方之后修改session不影响已经保存的数据
+	m.sessions[session.ChannelId] = &memorySession{
+		session:  proto.Clone(session).(*pkt.Session),
+		expireAt: expireAt,
+	}
+	return nil
+}
+
+// Delete a session
+func (m *MemoryStorage) Delete(account string, channelId string) error {
+	m.Lock()
+	defer m.Unlock()
+	m.delete(account, channelId)
+	return nil
+}
+
+func (m *MemoryStorage) delete(account string, channelId string) {
+	if locs, ok := m.locations[account]; ok {
+		delete(locs, channelId)
+		if len(locs) == 0 {
+			delete(m.locations, account)
+		}
+	}
+	delete(m.sessions, channelId)
+}
+
+// Get get session by channelId
+func (m *MemoryStorage) Get(channelId string) (*pkt.Session, error) {
+	m.Lock()
+	defer m.Unlock()
+	sn, ok := m.sessions[channelId]
+	if !ok || !m.now().Before(sn.expireAt) {
+		return nil, HopeIM.ErrSessionNil
+	}
+	return proto.Clone(sn.session).(*pkt.Session), nil
+}
+
+// GetLocations 返回账号在所有设备上的Location
+func (m *MemoryStorage) GetLocations(accounts ...string) ([]*HopeIM.Location, error) {
+	m.Lock()
+	defer m.Unlock()
+	now := m.now()
+	var result = make([]*HopeIM.Location, 0)
+	for _, account := range accounts {
+		for channelId, loc := range m.locations[account] {
+			if sn, ok := m.sessions[channelId]; !ok || !now.Before(sn.expireAt) {
+				continue
+			}
+			loc := loc
+			result = append(result, &loc)
+		}
+	}
+	if len(result) == 0 {
+		return nil, HopeIM.ErrSessionNil
+	}
+	return result, nil
+}
+
+// GetLocation 返回账号在device上的Location，device为空时返回任意一个设备
+func (m *MemoryStorage) GetLocation(account string, device string) (*HopeIM.Location, error) {
+	locs, err := m.GetLocations(account)
+	if err != nil {
+		return nil, err
+	}
+	for _, loc := range locs {
+		if device == "" || loc.Device == device {
+			return loc, nil
+		}
+	}
+	return nil, HopeIM.ErrSessionNil
+}
+
+// Touch 刷新会话的过期时间，Location随之刷新
+func (m *MemoryStorage) Touch(gateId string, sessions ...*pkt.TouchSession) ([]*pkt.TouchSession, error) {
+	m.Lock()
+	defer m.Unlock()
+	now := m.now()
+	expireAt := now.Add(m.ttl)
+	var touched []*pkt.TouchSession
+	for _, sn := range sessions {
+		s, ok := m.sessions[sn.ChannelId]
+		if !ok || !now.Before(s.expireAt) || s.session.GateId != gateId {
+			continue
+		}
+		s.expireAt = expireAt
+		touched = append(touched, sn)
+	}
+	return touched, nil
+}
+
+// sweep 每隔一个ttl清理一次过期的数据
+func (m *MemoryStorage) sweep(now time.Time) {
+	if now.Sub(m.lastSweep) < m.ttl {
+		return
+	}
+	m.lastSweep = now
+	for id, sn := range m.sessions {
+		if !now.Before(sn.expireAt) {
+			m.delete(sn.session.Account, id)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+	"github.com/sjmshsh/HopeIM"
+)
+
+// DeliveryExpired 待确认推送的过期时间，需要大于Delivery重传的总时长
+const DeliveryExpired = time.Minute * 10
+
+// RedisDeliveryStore 基于redis的DeliveryStore，每个channel一个hash，field为messageId，value为第一次推送的时间
+type RedisDeliveryStore struct {
+	cli *redis.Client
+}
+
+// NewRedisDeliveryStore NewRedisDeliveryStore
+func NewRedisDeliveryStore(cli *redis.Client) HopeIM.DeliveryStore {
+	return &RedisDeliveryStore{
+		cli: cli,
+	}
+}
+
+// Add Add
+func (r *RedisDeliveryStore) Add(channelId string, messageId int64, sent time.Time) error {
+	key := KeyDelivery(channelId)
+	_, err := r.cli.Pipelined(func(pipe redis.Pipeliner) error {
+		pipe.HSet(key, strconv.FormatInt(messageId, 10), sent.UnixNano())
+		pipe.Expire(key, DeliveryExpired)
+		return nil
+	})
+	return err
+}
+
+// Remove 在一个事务中读取并删除，保证同一条推送只有一个节点删除成功
+func (r *RedisDeliveryStore) Remove(channelId string, messageId int64) (time.Time, bool, error) {
+	key := KeyDelivery(channelId)
+	field := strconv.FormatInt(messageId, 10)
+	var get *redis.StringCmd
+	var del *redis.IntCmd
+	_, err := r.cli.TxPipelined(func(pipe redis.Pipeliner) error {
+		get = pipe.HGet(key, field)
+		del = pipe.HDel(key, field)
+		return nil
+	})
+	if err != nil && err != redis.Nil {
+		return time.Time{}, false, err
+	}
+	if del.Val() == 0 {
+		return time.Time{}, false, nil
+	}
+	sent, err := get.Int64()
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	return time.Unix(0, sent), true, nil
+}
+
+// Exists Exists
+func (r *RedisDeliveryStore) Exists(channelId string, messageId int64) (bool, error) {
+	return r.cli.HExists(KeyDelivery(channelId), strconv.FormatInt(messageId, 10)).Result()
+}
+
+// Clear Clear
+func (r *RedisDeliveryStore) Clear(channelId string) error {
+	return r.cli.Del(KeyDelivery(channelId)).Err()
+}
+
+func KeyDelivery(channel string) string {
+	return fmt.Sprintf("delivery:%s", channel)
+}
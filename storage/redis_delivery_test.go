@@ -0,0 +1,41 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v7"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedisDeliveryStore(t *testing.T) {
+	mr := miniredis.RunT(t)
+	cli := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer cli.Close()
+
+	s := NewRedisDeliveryStore(cli)
+	sent := time.Now()
+	assert.Nil(t, s.Add("ch1", 1, sent))
+	assert.True(t, mr.TTL(KeyDelivery("ch1")) > 0)
+
+	ok, err := s.Exists("ch1", 1)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	got, ok, err := s.Remove("ch1", 1)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, sent.UnixNano(), got.UnixNano())
+
+	// 只有一个节点能删除成功
+	_, ok, err = s.Remove("ch1", 1)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+	ok, _ = s.Exists("ch1", 1)
+	assert.False(t, ok)
+
+	assert.Nil(t, s.Add("ch1", 2, sent))
+	assert.Nil(t, s.Clear("ch1"))
+	assert.False(t, mr.Exists(KeyDelivery("ch1")))
+}
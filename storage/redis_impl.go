@@ -23,42 +23,36 @@ func NewRedisStorage(cli *redis.Client) HopeIM.SessionStorage {
 	}
 }
 
+// Add 保存会话，一个账号的每个会话在账号的hash中都有一个Location，field为ChannelId。
+// Location与会话在同一个事务中写入，它的有效期以会话的key为准，账号hash的过期时间只用于回收
 func (r *RedisStorage) Add(sesssion *pkt.Session) error {
 	// save Hope.Location
 	loc := HopeIM.Location{
-		ChannelId: sesssion.ChannelId,
-		GateId:    sesssion.GateId,
+		ChannelId:   sesssion.ChannelId,
+		GateId:      sesssion.GateId,
+		ContentType: sesssion.ContentType,
+		Device:      sesssion.Device,
 	}
-	locKey := KeyLocation(sesssion.Account, "")
-	err := r.cli.Set(locKey, loc.Bytes(), LocationExpired).Err()
-	if err != nil {
-		return err
-	}
-
-	// save session
-	snKey := KeySession(sesssion.ChannelId)
+	locKey := KeyLocation(sesssion.Account)
 	buf, _ := proto.Marshal(sesssion)
-	err = r.cli.Set(snKey, buf, LocationExpired).Err()
-	if err != nil {
-		return err
-	}
-	return nil
+	_, err := r.cli.TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.HSet(locKey, sesssion.ChannelId, loc.Bytes())
+		pipe.Expire(locKey, LocationExpired)
+		// save session
+		pipe.Set(KeySession(sesssion.ChannelId), buf, LocationExpired)
+		return nil
+	})
+	return err
 }
 
 // Delete a session
 func (r *RedisStorage) Delete(account string, channelId string) error {
-	locKey := KeyLocation(account, "")
-	err := r.cli.Del(locKey).Err()
-	if err != nil {
-		return err
-	}
-
-	snKey := KeySession(channelId)
-	err = r.cli.Del(snKey).Err()
-	if err != nil {
-		return err
-	}
-	return nil
+	_, err := r.cli.TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.HDel(KeyLocation(account), channelId)
+		pipe.Del(KeySession(channelId))
+		return nil
+	})
+	return err
 }
 
 // Get get session by
@@ -77,56 +71,164 @@ func (r *RedisStorage) Get(ChannelId string) (*pkt.Session, error) {
 	return &session, err
 }
 
+// GetLocations 返回账号在所有设备上的Location，会话已经过期的Location会被过滤并从hash中删除。
+// 升级之前保存在KeyLegacyLocation中的Location同样会被读取，会话有效时迁移到账号的hash中
 func (r *RedisStorage) GetLocations(accounts ...string) ([]*HopeIM.Location, error) {
-	keys := KeyLocations(accounts...)
-	list, err := r.cli.MGet(keys...).Result()
-	if err != nil {
+	pipe := r.cli.Pipeline()
+	cmds := make([]*redis.StringStringMapCmd, len(accounts))
+	legacies := make([]*redis.StringCmd, len(accounts))
+	for i, account := range accounts {
+		cmds[i] = pipe.HGetAll(KeyLocation(account))
+		legacies[i] = pipe.Get(KeyLegacyLocation(account))
+	}
+	if _, err := pipe.Exec(); err != nil && err != redis.Nil {
 		return nil, err
 	}
-	var result = make([]*HopeIM.Location, 0)
-	for _, l := range list {
-		if l == nil {
+	type field struct {
+		account string
+		channel string
+		loc     string
+		legacy  bool
+	}
+	var fields []field
+	for i, cmd := range cmds {
+		locs := cmd.Val()
+		for channel, loc := range locs {
+			fields = append(fields, field{accounts[i], channel, loc, false})
+		}
+		val, err := legacies[i].Result()
+		if err != nil {
 			continue
 		}
 		var loc HopeIM.Location
-		_ = loc.Unmarshal([]byte(l.(string)))
+		if err := loc.Unmarshal([]byte(val)); err != nil {
+			continue
+		}
+		if _, ok := locs[loc.ChannelId]; !ok {
+			fields = append(fields, field{accounts[i], loc.ChannelId, val, true})
+		}
+	}
+	if len(fields) == 0 {
+		return nil, HopeIM.ErrSessionNil
+	}
+	// 检查每个Location的会话是否还存在
+	pipe = r.cli.Pipeline()
+	exists := make([]*redis.IntCmd, len(fields))
+	for i, f := range fields {
+		exists[i] = pipe.Exists(KeySession(f.channel))
+	}
+	if _, err := pipe.Exec(); err != nil {
+		return nil, err
+	}
+	var result = make([]*HopeIM.Location, 0, len(fields))
+	fix := r.cli.Pipeline()
+	var fixed bool
+	for i, f := range fields {
+		if f.legacy {
+			fix.Del(KeyLegacyLocation(f.account))
+			fixed = true
+			if exists[i].Val() != 0 {
+				fix.HSetNX(KeyLocation(f.account), f.channel, f.loc)
+				fix.Expire(KeyLocation(f.account), LocationExpired)
+			}
+		}
+		if exists[i].Val() == 0 {
+			if !f.legacy {
+				fix.HDel(KeyLocation(f.account), f.channel)
+				fixed = true
+			}
+			continue
+		}
+		var loc HopeIM.Location
+		if err := loc.Unmarshal([]byte(f.loc)); err != nil {
+			continue
+		}
 		result = append(result, &loc)
 	}
+	if fixed {
+		_, _ = fix.Exec()
+	}
 	if len(result) == 0 {
 		return nil, HopeIM.ErrSessionNil
 	}
 	return result, nil
 }
 
+// GetLocation 返回账号在device上的Location，device为空时返回任意一个设备
 func (r *RedisStorage) GetLocation(account string, device string) (*HopeIM.Location, error) {
-	key := KeyLocation(account, device)
-	bts, err := r.cli.Get(key).Bytes()
+	locs, err := r.GetLocations(account)
 	if err != nil {
-		if err == redis.Nil {
-			return nil, HopeIM.ErrSessionNil
+		return nil, err
+	}
+	for _, loc := range locs {
+		if device == "" || loc.Device == device {
+			return loc, nil
+		}
+	}
+	return nil, HopeIM.ErrSessionNil
+}
+
+// Touch 先读取会话，只刷新保存在gateId上的会话，再在一个pipeline中刷新会话与账号hash的过期时间，
+// EXPIRE不会创建已经不存在的key。账号hash被刷新时其中已经过期的会话不会被恢复，GetLocations按照会话的key过滤
+func (r *RedisStorage) Touch(gateId string, sessions ...*pkt.TouchSession) ([]*pkt.TouchSession, error) {
+	if len(sessions) == 0 {
+		return nil, nil
+	}
+	pipe := r.cli.Pipeline()
+	gets := make([]*redis.StringCmd, len(sessions))
+	for i, sn := range sessions {
+		gets[i] = pipe.Get(KeySession(sn.ChannelId))
+	}
+	if _, err := pipe.Exec(); err != nil && err != redis.Nil {
+		return nil, err
+	}
+	var touched []*pkt.TouchSession
+	accounts := make(map[string]struct{})
+	pipe = r.cli.Pipeline()
+	for i, sn := range sessions {
+		bts, err := gets[i].Bytes()
+		if err != nil {
+			continue
 		}
+		var session pkt.Session
+		if err = proto.Unmarshal(bts, &session); err != nil || session.GateId != gateId {
+			continue
+		}
+		touched = append(touched, sn)
+		pipe.Expire(KeySession(sn.ChannelId), LocationExpired)
+		if _, ok := accounts[session.Account]; ok {
+			continue
+		}
+		accounts[session.Account] = struct{}{}
+		pipe.Expire(KeyLocation(session.Account), LocationExpired)
+	}
+	if len(touched) == 0 {
+		return nil, nil
+	}
+	if _, err := pipe.Exec(); err != nil {
 		return nil, err
 	}
-	var loc HopeIM.Location
-	_ = loc.Unmarshal(bts)
-	return &loc, nil
+	return touched, nil
 }
 
 func KeySession(channel string) string {
 	return fmt.Sprintf("login:sn:%s", channel)
 }
 
-func KeyLocation(account, device string) string {
-	if device == "" {
-		return fmt.Sprintf("login:loc:%s", account)
-	}
-	return fmt.Sprintf("login:loc:%s:%s", account, device)
+// KeyLocation 账号所有会话的Location，是一个hash
+func KeyLocation(account string) string {
+	return fmt.Sprintf("login:locs:%s", account)
+}
+
+// KeyLegacyLocation 升级之前账号唯一会话的Location，是一个string，只在GetLocations中读取并迁移
+func KeyLegacyLocation(account string) string {
+	return fmt.Sprintf("login:loc:%s", account)
 }
 
 func KeyLocations(accounts ...string) []string {
 	arr := make([]string, len(accounts))
 	for i, account := range accounts {
-		arr[i] = KeyLocation(account, "")
+		arr[i] = KeyLocation(account)
 	}
 	return arr
 }
@@ -0,0 +1,128 @@
+package storage
+
+import (
+	"fmt"
+
+	"github.com/go-redis/redis/v7"
+	"github.com/sjmshsh/HopeIM"
+)
+
+// RedisPresenceStorage 基于redis的PresenceStorage，自定义状态与订阅关系都使用LocationExpired过期
+type RedisPresenceStorage struct {
+	cli *redis.Client
+}
+
+// NewRedisPresenceStorage NewRedisPresenceStorage
+func NewRedisPresenceStorage(cli *redis.Client) HopeIM.PresenceStorage {
+	return &RedisPresenceStorage{
+		cli: cli,
+	}
+}
+
+// SetStatus 设置自定义状态，status为空时清除
+func (r *RedisPresenceStorage) SetStatus(account string, status string) error {
+	if status == "" {
+		return r.cli.Del(KeyPresenceStatus(account)).Err()
+	}
+	return r.cli.Set(KeyPresenceStatus(account), status, LocationExpired).Err()
+}
+
+// GetStatus 返回账号的自定义状态
+func (r *RedisPresenceStorage) GetStatus(accounts ...string) (map[string]string, error) {
+	result := make(map[string]string, len(accounts))
+	if len(accounts) == 0 {
+		return result, nil
+	}
+	keys := make([]string, len(accounts))
+	for i, account := range accounts {
+		keys[i] = KeyPresenceStatus(account)
+	}
+	values, err := r.cli.MGet(keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+	for i, value := range values {
+		if status, ok := value.(string); ok && status != "" {
+			result[accounts[i]] = status
+		}
+	}
+	return result, nil
+}
+
+// Subscribe 在每个被订阅账号的集合中添加subscriber，同时记录subscriber订阅的账号，用于Touch
+func (r *RedisPresenceStorage) Subscribe(subscriber string, accounts ...string) error {
+	_, err := r.cli.Pipelined(func(pipe redis.Pipeliner) error {
+		subscriptions := KeyPresenceSubscriptions(subscriber)
+		for _, account := range accounts {
+			key := KeyPresenceSubscribers(account)
+			pipe.SAdd(key, subscriber)
+			pipe.Expire(key, LocationExpired)
+			pipe.SAdd(subscriptions, account)
+		}
+		pipe.Expire(subscriptions, LocationExpired)
+		return nil
+	})
+	return err
+}
+
+// Unsubscribe 取消订阅
+func (r *RedisPresenceStorage) Unsubscribe(subscriber string, accounts ...string) error {
+	_, err := r.cli.Pipelined(func(pipe redis.Pipeliner) error {
+		subscriptions := KeyPresenceSubscriptions(subscriber)
+		for _, account := range accounts {
+			pipe.SRem(KeyPresenceSubscribers(account), subscriber)
+			pipe.SRem(subscriptions, account)
+		}
+		return nil
+	})
+	return err
+}
+
+// Subscribers 返回订阅了account的账号
+func (r *RedisPresenceStorage) Subscribers(account string) ([]string, error) {
+	return r.cli.SMembers(KeyPresenceSubscribers(account)).Result()
+}
+
+// Touch 刷新账号的自定义状态、它的订阅者以及它订阅的账号的过期时间，EXPIRE不会创建已经不存在的key
+func (r *RedisPresenceStorage) Touch(accounts ...string) error {
+	if len(accounts) == 0 {
+		return nil
+	}
+	pipe := r.cli.Pipeline()
+	cmds := make([]*redis.StringSliceCmd, len(accounts))
+	for i, account := range accounts {
+		pipe.Expire(KeyPresenceStatus(account), LocationExpired)
+		pipe.Expire(KeyPresenceSubscribers(account), LocationExpired)
+		pipe.Expire(KeyPresenceSubscriptions(account), LocationExpired)
+		cmds[i] = pipe.SMembers(KeyPresenceSubscriptions(account))
+	}
+	if _, err := pipe.Exec(); err != nil {
+		return err
+	}
+	pipe = r.cli.Pipeline()
+	var n int
+	for _, cmd := range cmds {
+		for _, account := range cmd.Val() {
+			pipe.Expire(KeyPresenceSubscribers(account), LocationExpired)
+			n++
+		}
+	}
+	if n == 0 {
+		return nil
+	}
+	_, err := pipe.Exec()
+	return err
+}
+
+func KeyPresenceStatus(account string) string {
+	return fmt.Sprintf("presence:status:%s", account)
+}
+
+func KeyPresenceSubscribers(account string) string {
+	return fmt.Sprintf("presence:subs:%s", account)
+}
+
+// KeyPresenceSubscriptions 账号订阅的账号
+func KeyPresenceSubscriptions(account string) string {
+	return fmt.Sprintf("presence:subto:%s", account)
+}
@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v7"
+	"github.com/sjmshsh/HopeIM/wire"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedisPresenceStorage(t *testing.T) {
+	mr := miniredis.RunT(t)
+	cli := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer cli.Close()
+
+	s := NewRedisPresenceStorage(cli)
+
+	assert.Nil(t, s.SetStatus("test1", wire.PresenceBusy))
+	status, err := s.GetStatus("test1", "test2")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"test1": wire.PresenceBusy}, status)
+
+	assert.Nil(t, s.SetStatus("test1", ""))
+	status, err = s.GetStatus("test1")
+	assert.Nil(t, err)
+	assert.Empty(t, status)
+
+	assert.Nil(t, s.Subscribe("test2", "test1", "test3"))
+	assert.Nil(t, s.Subscribe("test3", "test1"))
+	subs, err := s.Subscribers("test1")
+	assert.Nil(t, err)
+	sort.Strings(subs)
+	assert.Equal(t, []string{"test2", "test3"}, subs)
+	assert.True(t, mr.TTL(KeyPresenceSubscribers("test1")) > 0)
+
+	assert.Nil(t, s.Unsubscribe("test2", "test1"))
+	subs, _ = s.Subscribers("test1")
+	assert.Equal(t, []string{"test3"}, subs)
+	subs, _ = s.Subscribers("test4")
+	assert.Empty(t, subs)
+}
+
+func TestRedisPresenceStorage_Touch(t *testing.T) {
+	mr := miniredis.RunT(t)
+	cli := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer cli.Close()
+
+	s := NewRedisPresenceStorage(cli)
+	assert.Nil(t, s.SetStatus("test1", wire.PresenceBusy))
+	assert.Nil(t, s.Subscribe("test1", "test2"))
+	assert.Nil(t, s.Subscribe("test3", "test1"))
+
+	mr.FastForward(LocationExpired - time.Minute)
+	assert.Nil(t, s.Touch())
+	assert.Nil(t, s.Touch("test1"))
+	mr.FastForward(time.Minute * 2)
+
+	// 在线账号的状态、订阅者与它订阅的关系都没有过期
+	status, _ := s.GetStatus("test1")
+	assert.Equal(t, map[string]string{"test1": wire.PresenceBusy}, status)
+	subs, _ := s.Subscribers("test1")
+	assert.Equal(t, []string{"test3"}, subs)
+	subs, _ = s.Subscribers("test2")
+	assert.Equal(t, []string{"test1"}, subs)
+
+	// 取消订阅之后不再刷新
+	assert.Nil(t, s.Unsubscribe("test1", "test2"))
+	members, _ := mr.Members(KeyPresenceSubscriptions("test1"))
+	assert.Empty(t, members)
+}
@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+	"github.com/sjmshsh/HopeIM"
+)
+
+// tokenBucketScript 在redis中原子地检查多个令牌桶，所有令牌桶都有令牌时才各取一个令牌
+// KEYS[i] 第i个令牌桶的key
+// ARGV[1] 当前时间(毫秒) ARGV[2i] 第i个令牌桶每秒生成的令牌数 ARGV[2i+1] 第i个令牌桶的容量
+var tokenBucketScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local buckets = {}
+for i, key in ipairs(KEYS) do
+    local rate = tonumber(ARGV[2 * i])
+    local burst = tonumber(ARGV[2 * i + 1])
+    local bucket = redis.call('HMGET', key, 'tokens', 'ts')
+    local tokens = tonumber(bucket[1])
+    local ts = tonumber(bucket[2])
+    if tokens == nil or ts == nil then
+        tokens = burst
+        ts = now
+    end
+    if now > ts then
+        tokens = math.min(burst, tokens + (now - ts) / 1000 * rate)
+        ts = now
+    end
+    if tokens < 1 then
+        return 0
+    end
+    buckets[i] = {tokens - 1, ts, math.ceil(burst / rate * 1000) + 1000}
+end
+for i, key in ipairs(KEYS) do
+    local b = buckets[i]
+    redis.call('HMSET', key, 'tokens', tostring(b[1]), 'ts', tostring(b[2]))
+    redis.call('PEXPIRE', key, b[3])
+end
+return 1
+`)
+
+// RedisRateLimiter 基于redis的分布式RateLimiter，多个节点共享令牌桶
+type RedisRateLimiter struct {
+	cli *redis.Client
+}
+
+// NewRedisRateLimiter NewRedisRateLimiter
+func NewRedisRateLimiter(cli *redis.Client) HopeIM.RateLimiter {
+	return &RedisRateLimiter{
+		cli: cli,
+	}
+}
+
+// Allow 在一次lua调用中检查并消耗所有规则的令牌
+func (r *RedisRateLimiter) Allow(ctx context.Context, rules ...HopeIM.RateLimitRule) (bool, error) {
+	if len(rules) == 0 {
+		return true, nil
+	}
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+	keys := make([]string, len(rules))
+	args := make([]interface{}, 0, 1+len(rules)*2)
+	args = append(args, now)
+	for i, rule := range rules {
+		burst := rule.Limit.Burst
+		if burst < 1 {
+			burst = 1
+		}
+		keys[i] = KeyRateLimit(rule.Key)
+		args = append(args, rule.Limit.Rate, burst)
+	}
+	allowed, err := tokenBucketScript.Run(r.cli.WithContext(ctx), keys, args...).Int()
+	if err != nil {
+		return false, err
+	}
+	return allowed == 1, nil
+}
+
+func KeyRateLimit(key string) string {
+	return fmt.Sprintf("ratelimit:%s", key)
+}
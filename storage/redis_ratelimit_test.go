@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v7"
+	"github.com/sjmshsh/HopeIM"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedisRateLimiter(t *testing.T) {
+	mr := miniredis.RunT(t)
+	cli := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer cli.Close()
+
+	limiter := NewRedisRateLimiter(cli)
+	limit := HopeIM.RateLimit{Rate: 100, Burst: 2}
+	ctx := context.Background()
+
+	rule := HopeIM.RateLimitRule{Key: "account:test1", Limit: limit}
+
+	ok, err := limiter.Allow(ctx, rule)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	ok, _ = limiter.Allow(ctx, rule)
+	assert.True(t, ok)
+	ok, _ = limiter.Allow(ctx, rule)
+	assert.False(t, ok)
+	// 其它key使用独立的令牌桶
+	ok, _ = limiter.Allow(ctx, HopeIM.RateLimitRule{Key: "account:test2", Limit: limit})
+	assert.True(t, ok)
+	assert.True(t, mr.TTL(KeyRateLimit("account:test1")) > 0)
+
+	// 按照Rate补充令牌
+	time.Sleep(time.Millisecond * 20)
+	ok, _ = limiter.Allow(ctx, rule)
+	assert.True(t, ok)
+}
+
+func TestRedisRateLimiter_AllOrNothing(t *testing.T) {
+	mr := miniredis.RunT(t)
+	cli := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer cli.Close()
+
+	limiter := NewRedisRateLimiter(cli)
+	limit := HopeIM.RateLimit{Rate: 0.001, Burst: 1}
+	cmd := HopeIM.RateLimitRule{Key: "cmd:test1", Limit: limit}
+	account := HopeIM.RateLimitRule{Key: "account:test1", Limit: limit}
+	app := HopeIM.RateLimitRule{Key: "app:hopeim", Limit: limit}
+	ctx := context.Background()
+
+	ok, err := limiter.Allow(ctx, app)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	// app的令牌桶已经空了，cmd与account的令牌不会被消耗
+	ok, _ = limiter.Allow(ctx, cmd, account, app)
+	assert.False(t, ok)
+	ok, _ = limiter.Allow(ctx, cmd, account)
+	assert.True(t, ok)
+	ok, _ = limiter.Allow(ctx, cmd)
+	assert.False(t, ok)
+}
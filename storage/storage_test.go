@@ -0,0 +1,224 @@
+package storage
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v7"
+	"github.com/golang/protobuf/proto"
+	"github.com/sjmshsh/HopeIM"
+	"github.com/sjmshsh/HopeIM/wire"
+	"github.com/sjmshsh/HopeIM/wire/pkt"
+	"github.com/stretchr/testify/assert"
+)
+
+// storageFactory 创建一个空的SessionStorage，advance让存储的时间前进d
+type storageFactory func(t *testing.T) (s HopeIM.SessionStorage, advance func(d time.Duration))
+
+// testSessionStorage 所有SessionStorage的实现都需要通过的测试
+func testSessionStorage(t *testing.T, factory storageFactory) {
+	t.Run("Get", func(t *testing.T) {
+		s, _ := factory(t)
+		_, err := s.Get("ch1")
+		assert.Equal(t, HopeIM.ErrSessionNil, err)
+
+		session := &pkt.Session{ChannelId: "ch1", GateId: "gate1", Account: "test1", App: "im", Tags: []string{"t1"}}
+		assert.Nil(t, s.Add(session))
+		got, err := s.Get("ch1")
+		assert.Nil(t, err)
+		assert.Equal(t, session.String(), got.String())
+	})
+
+	t.Run("MultiDevice", func(t *testing.T) {
+		s, _ := factory(t)
+		assert.Nil(t, s.Add(&pkt.Session{ChannelId: "ch1", GateId: "gate1", Account: "test1", Device: wire.DeviceIOS}))
+		assert.Nil(t, s.Add(&pkt.Session{ChannelId: "ch2", GateId: "gate2", Account: "test1", Device: wire.DevicePC,
+			ContentType: pkt.ContentType_Protobuf}))
+		assert.Nil(t, s.Add(&pkt.Session{ChannelId: "ch3", GateId: "gate1", Account: "test2", Device: wire.DeviceWeb}))
+
+		locs, err := s.GetLocations("test1", "test2", "test3")
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"ch1", "ch2", "ch3"}, channelIds(locs))
+
+		loc, err := s.GetLocation("test1", wire.DevicePC)
+		assert.Nil(t, err)
+		assert.Equal(t, HopeIM.Location{ChannelId: "ch2", GateId: "gate2", Device: wire.DevicePC,
+			ContentType: pkt.ContentType_Protobuf}, *loc)
+		_, err = s.GetLocation("test1", wire.DeviceAndroid)
+		assert.Equal(t, HopeIM.ErrSessionNil, err)
+		_, err = s.GetLocation("test1", "")
+		assert.Nil(t, err)
+
+		// 只删除一个设备的会话
+		assert.Nil(t, s.Delete("test1", "ch1"))
+		locs, _ = s.GetLocations("test1")
+		assert.Equal(t, []string{"ch2"}, channelIds(locs))
+		_, err = s.Get("ch1")
+		assert.Equal(t, HopeIM.ErrSessionNil, err)
+
+		assert.Nil(t, s.Delete("test1", "ch2"))
+		_, err = s.GetLocations("test1")
+		assert.Equal(t, HopeIM.ErrSessionNil, err)
+		// 删除不存在的会话不是错误
+		assert.Nil(t, s.Delete("test1", "ch2"))
+	})
+
+	t.Run("Expire", func(t *testing.T) {
+		s, advance := factory(t)
+		assert.Nil(t, s.Add(&pkt.Session{ChannelId: "ch1", GateId: "gate1", Account: "test1"}))
+		advance(LocationExpired - time.Minute)
+		_, err := s.Get("ch1")
+		assert.Nil(t, err)
+
+		advance(time.Minute)
+		_, err = s.Get("ch1")
+		assert.Equal(t, HopeIM.ErrSessionNil, err)
+		_, err = s.GetLocations("test1")
+		assert.Equal(t, HopeIM.ErrSessionNil, err)
+		_, err = s.GetLocation("test1", "")
+		assert.Equal(t, HopeIM.ErrSessionNil, err)
+	})
+
+	t.Run("Touch", func(t *testing.T) {
+		s, advance := factory(t)
+		assert.Nil(t, s.Add(&pkt.Session{ChannelId: "ch1", GateId: "gate1", Account: "test1"}))
+		assert.Nil(t, s.Add(&pkt.Session{ChannelId: "ch2", GateId: "gate1", Account: "test2"}))
+		_, err := s.Touch("gate1")
+		assert.Nil(t, err)
+
+		advance(LocationExpired - time.Minute)
+		touch(t, s, &pkt.TouchSession{ChannelId: "ch1", Account: "test1"})
+		advance(time.Minute * 2)
+
+		// 活跃的会话一直有效
+		_, err = s.Get("ch1")
+		assert.Nil(t, err)
+		locs, err := s.GetLocations("test1", "test2")
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"ch1"}, channelIds(locs))
+		_, err = s.Get("ch2")
+		assert.Equal(t, HopeIM.ErrSessionNil, err)
+
+		// 过期的会话不会被恢复
+		touch(t, s, &pkt.TouchSession{ChannelId: "ch2", Account: "test2"})
+		_, err = s.Get("ch2")
+		assert.Equal(t, HopeIM.ErrSessionNil, err)
+		_, err = s.GetLocations("test2")
+		assert.Equal(t, HopeIM.ErrSessionNil, err)
+	})
+
+	t.Run("TouchOtherGateway", func(t *testing.T) {
+		s, advance := factory(t)
+		assert.Nil(t, s.Add(&pkt.Session{ChannelId: "ch1", GateId: "gate1", Account: "test1"}))
+		advance(LocationExpired - time.Minute)
+
+		// 只有会话所在的网关能续期
+		touched, err := s.Touch("gate2", &pkt.TouchSession{ChannelId: "ch1", Account: "test1"})
+		assert.Nil(t, err)
+		assert.Empty(t, touched)
+		advance(time.Minute * 2)
+		_, err = s.Get("ch1")
+		assert.Equal(t, HopeIM.ErrSessionNil, err)
+	})
+
+	t.Run("ExpirePerChannel", func(t *testing.T) {
+		s, advance := factory(t)
+		assert.Nil(t, s.Add(&pkt.Session{ChannelId: "ch1", GateId: "gate1", Account: "test1", Device: wire.DeviceIOS}))
+		advance(LocationExpired - time.Minute)
+		// 同一个账号的新会话与心跳不会延长其它会话的Location
+		assert.Nil(t, s.Add(&pkt.Session{ChannelId: "ch2", GateId: "gate1", Account: "test1", Device: wire.DevicePC}))
+		touch(t, s, &pkt.TouchSession{ChannelId: "ch2", Account: "test1"})
+		advance(time.Minute * 2)
+
+		locs, err := s.GetLocations("test1")
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"ch2"}, channelIds(locs))
+		_, err = s.GetLocation("test1", wire.DeviceIOS)
+		assert.Equal(t, HopeIM.ErrSessionNil, err)
+		// 过期的会话不会被心跳恢复
+		touch(t, s, &pkt.TouchSession{ChannelId: "ch1", Account: "test1"})
+		locs, _ = s.GetLocations("test1")
+		assert.Equal(t, []string{"ch2"}, channelIds(locs))
+	})
+}
+
+func touch(t *testing.T, s HopeIM.SessionStorage, sessions ...*pkt.TouchSession) {
+	_, err := s.Touch("gate1", sessions...)
+	assert.Nil(t, err)
+}
+
+func channelIds(locs []*HopeIM.Location) []string {
+	ids := make([]string, len(locs))
+	for i, loc := range locs {
+		ids[i] = loc.ChannelId
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func TestRedisStorage(t *testing.T) {
+	testSessionStorage(t, func(t *testing.T) (HopeIM.SessionStorage, func(time.Duration)) {
+		mr := miniredis.RunT(t)
+		cli := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+		t.Cleanup(func() { _ = cli.Close() })
+		return NewRedisStorage(cli), mr.FastForward
+	})
+}
+
+func TestRedisStorage_DeleteStaleLocations(t *testing.T) {
+	mr := miniredis.RunT(t)
+	cli := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer cli.Close()
+	s := NewRedisStorage(cli)
+
+	assert.Nil(t, s.Add(&pkt.Session{ChannelId: "ch1", GateId: "gate1", Account: "test1"}))
+	assert.Nil(t, s.Add(&pkt.Session{ChannelId: "ch2", GateId: "gate1", Account: "test1"}))
+	mr.Del(KeySession("ch1"))
+
+	locs, err := s.GetLocations("test1")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"ch2"}, channelIds(locs))
+	fields, _ := mr.HKeys(KeyLocation("test1"))
+	assert.Equal(t, []string{"ch2"}, fields)
+}
+
+func TestMemoryStorage(t *testing.T) {
+	testSessionStorage(t, func(t *testing.T) (HopeIM.SessionStorage, func(time.Duration)) {
+		s := NewMemoryStorage(0).(*MemoryStorage)
+		now := time.Now()
+		s.now = func() time.Time { return now }
+		return s, func(d time.Duration) { now = now.Add(d) }
+	})
+}
+
+func TestRedisStorage_LegacyLocation(t *testing.T) {
+	mr := miniredis.RunT(t)
+	cli := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer cli.Close()
+	s := NewRedisStorage(cli)
+
+	// 升级之前登录的会话只有KeyLegacyLocation
+	buf, _ := proto.Marshal(&pkt.Session{ChannelId: "ch1", GateId: "gate1", Account: "test1"})
+	_ = mr.Set(KeySession("ch1"), string(buf))
+	loc := HopeIM.Location{ChannelId: "ch1", GateId: "gate1"}
+	_ = mr.Set(KeyLegacyLocation("test1"), string(loc.Bytes()))
+	_ = mr.Set(KeyLegacyLocation("test2"), string((&HopeIM.Location{ChannelId: "ch2", GateId: "gate1"}).Bytes()))
+
+	locs, err := s.GetLocations("test1")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"ch1"}, channelIds(locs))
+	// 迁移到账号的hash中
+	assert.False(t, mr.Exists(KeyLegacyLocation("test1")))
+	fields, _ := mr.HKeys(KeyLocation("test1"))
+	assert.Equal(t, []string{"ch1"}, fields)
+	locs, err = s.GetLocations("test1")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"ch1"}, channelIds(locs))
+
+	// 会话已经过期的直接删除
+	_, err = s.GetLocations("test2")
+	assert.Equal(t, HopeIM.ErrSessionNil, err)
+	assert.False(t, mr.Exists(KeyLegacyLocation("test2")))
+}
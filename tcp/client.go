@@ -1,8 +1,11 @@
 package tcp
 
 import (
+	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
+	"net"
 	"net/url"
 	"sync"
 	"sync/atomic"
@@ -10,6 +13,8 @@ import (
 
 	"github.com/sjmshsh/HopeIM"
 	"github.com/sjmshsh/HopeIM/logger"
+	"github.com/sjmshsh/HopeIM/wire"
+	"github.com/sjmshsh/HopeIM/wire/pkt"
 )
 
 // ClientOptions ClientOptions
@@ -17,6 +22,11 @@ type ClientOptions struct {
 	Heartbeat time.Duration //登录超时
 	ReadWait  time.Duration //读超时
 	WriteWait time.Duration //写超时
+	TLSConfig *tls.Config   //不为nil时使用TLS连接
+	// MaxFrameSize 读取的帧的最大负载，为0时使用wire.DefaultMaxFrameSize
+	MaxFrameSize int
+	// Reconnect 不为nil时连接断开之后自动重连，Read会等待重连完成之后继续读取
+	Reconnect *HopeIM.ReconnectOptions
 }
 
 // Client is a websocket implement of the terminal
@@ -26,30 +36,42 @@ type Client struct {
 	once    sync.Once
 	id      string
 	name    string
+	addr    string
 	conn    HopeIM.Conn
+	gen     uint64
 	state   int32
 	options ClientOptions
 	Meta    map[string]string
+	done    chan struct{}
+
+	reconnector *HopeIM.Reconnector
+
+	reqonce   sync.Once
+	requester *HopeIM.Requester
 }
 
 // NewClient NewClient
-func NewClient(id, name string, opts ClientOptions) HopeIM.Client {
+func NewClient(id, name string, opts ClientOptions) HopeIM.RequestClient {
 	return NewClientWithProps(id, name, make(map[string]string), opts)
 }
 
-func NewClientWithProps(id, name string, meta map[string]string, opts ClientOptions) HopeIM.Client {
+func NewClientWithProps(id, name string, meta map[string]string, opts ClientOptions) HopeIM.RequestClient {
 	if opts.WriteWait == 0 {
 		opts.WriteWait = HopeIM.DefaultWriteWait
 	}
 	if opts.ReadWait == 0 {
 		opts.ReadWait = HopeIM.DefaultReadWait
 	}
+	if opts.MaxFrameSize == 0 {
+		opts.MaxFrameSize = wire.DefaultMaxFrameSize
+	}
 
 	cli := &Client{
 		id:      id,
 		name:    name,
 		options: opts,
 		Meta:    meta,
+		done:    make(chan struct{}),
 	}
 	return cli
 }
@@ -64,21 +86,18 @@ func (c *Client) Connect(addr string) error {
 	if !atomic.CompareAndSwapInt32(&c.state, 0, 1) {
 		return fmt.Errorf("client has connected")
 	}
+	c.addr = addr
 
-	rawconn, err := c.Dialer.DialAndHandshake(HopeIM.DialerContext{
-		Id:      c.id,
-		Name:    c.name,
-		Address: addr,
-		Timeout: HopeIM.DefaultLoginWait,
-	})
+	if c.options.Reconnect != nil {
+		c.reconnector = HopeIM.NewReconnector(*c.options.Reconnect, c.dial, c.write)
+		err = c.reconnector.Connect()
+	} else {
+		err = c.dial(1)
+	}
 	if err != nil {
 		atomic.CompareAndSwapInt32(&c.state, 1, 0)
 		return err
 	}
-	if rawconn == nil {
-		return fmt.Errorf("conn is nil")
-	}
-	c.conn = NewConn(rawconn)
 
 	if c.options.Heartbeat > 0 {
 		go func() {
@@ -91,6 +110,73 @@ func (c *Client) Connect(addr string) error {
 	return nil
 }
 
+// dial 拨号并握手，成功之后替换当前的连接，重连时同样调用它
+func (c *Client) dial(gen uint64) error {
+	rawconn, err := c.Dialer.DialAndHandshake(HopeIM.DialerContext{
+		Id:        c.id,
+		Name:      c.name,
+		Address:   c.addr,
+		Timeout:   HopeIM.DefaultLoginWait,
+		TLSConfig: c.options.TLSConfig,
+	})
+	if err != nil {
+		return err
+	}
+	if rawconn == nil {
+		return fmt.Errorf("conn is nil")
+	}
+	conn := NewConn(rawconn)
+	conn.SetMaxFrameSize(c.options.MaxFrameSize)
+
+	c.Lock()
+	defer c.Unlock()
+	select {
+	case <-c.done:
+		_ = rawconn.Close()
+		return HopeIM.ErrClientClosed
+	default:
+	}
+	if c.conn != nil {
+		_ = c.conn.Close()
+	}
+	c.conn = conn
+	c.gen = gen
+	return nil
+}
+
+func (c *Client) current() (HopeIM.Conn, uint64) {
+	c.Lock()
+	defer c.Unlock()
+	return c.conn, c.gen
+}
+
+// Request 发送一个请求，并等待Sequence相同的响应。
+// 调用之后读操作由内部的读循环接管，不能再直接调用Read
+func (c *Client) Request(ctx context.Context, p *pkt.LogicPkt) (*pkt.LogicPkt, error) {
+	return c.getRequester().Request(ctx, p)
+}
+
+// SetPushHandler 设置服务端推送消息的回调，同样会接管读操作
+func (c *Client) SetPushHandler(handler HopeIM.PushHandler) {
+	c.getRequester().SetPushHandler(handler)
+}
+
+func (c *Client) getRequester() *HopeIM.Requester {
+	c.reqonce.Do(func() {
+		c.requester = HopeIM.NewRequester(c)
+	})
+	return c.requester
+}
+
+// Dial 拨号建立一个tcp连接，config不为nil时使用TLS
+func Dial(address string, timeout time.Duration, config *tls.Config) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: timeout}
+	if config != nil {
+		return tls.DialWithDialer(dialer, "tcp", address, config)
+	}
+	return dialer.Dial("tcp", address)
+}
+
 // SetDialer 设置握手逻辑
 func (c *Client) SetDialer(dialer HopeIM.Dialer) {
 	c.Dialer = dialer
@@ -101,18 +187,49 @@ func (c *Client) Send(payload []byte) error {
 	if atomic.LoadInt32(&c.state) == 0 {
 		return fmt.Errorf("connection is nil")
 	}
+	for {
+		if c.reconnector != nil {
+			// 重连期间写入缓存
+			buffered, err := c.reconnector.Buffer(payload)
+			if buffered || err != nil {
+				return err
+			}
+		}
+		c.Lock()
+		gen := c.gen
+		err := c.writeFrame(HopeIM.OpBinary, payload)
+		c.Unlock()
+		if err == nil || c.reconnector == nil {
+			return err
+		}
+		c.reconnector.Disconnected(gen, err)
+	}
+}
+
+// write 在当前连接上写入，用于重连之后发送缓存的数据
+func (c *Client) write(payload []byte) error {
 	c.Lock()
 	defer c.Unlock()
+	return c.writeFrame(HopeIM.OpBinary, payload)
+}
+
+func (c *Client) writeFrame(code HopeIM.OpCode, payload []byte) error {
 	err := c.conn.SetWriteDeadline(time.Now().Add(c.options.WriteWait))
 	if err != nil {
 		return err
 	}
-	return c.conn.WriteFrame(HopeIM.OpBinary, payload)
+	return c.conn.WriteFrame(code, payload)
 }
 
 // Close 关闭
 func (c *Client) Close() {
 	c.once.Do(func() {
+		close(c.done)
+		if c.reconnector != nil {
+			c.reconnector.Close()
+		}
+		c.Lock()
+		defer c.Unlock()
 		if c.conn == nil {
 			return
 		}
@@ -124,18 +241,38 @@ func (c *Client) Close() {
 	})
 }
 
+// Read 读取一帧，开启重连时连接断开之后等待重连完成再继续读取，
+// 只有在Close或者放弃重连之后才返回错误
 func (c *Client) Read() (HopeIM.Frame, error) {
-	if c.conn == nil {
-		return nil, errors.New("connection is nil")
+	for {
+		conn, gen := c.current()
+		if conn == nil {
+			return nil, errors.New("connection is nil")
+		}
+		frame, err := c.readFrom(conn)
+		if err == nil {
+			return frame, nil
+		}
+		if c.reconnector == nil {
+			return nil, err
+		}
+		c.reconnector.Disconnected(gen, err)
+		if err := c.reconnector.Wait(); err != nil {
+			return nil, err
+		}
 	}
+}
+
+func (c *Client) readFrom(conn HopeIM.Conn) (HopeIM.Frame, error) {
 	if c.options.Heartbeat > 0 {
-		_ = c.conn.SetReadDeadline(time.Now().Add(c.options.ReadWait))
+		_ = conn.SetReadDeadline(time.Now().Add(c.options.ReadWait))
 	}
-	frame, err := c.conn.ReadFrame()
+	frame, err := conn.ReadFrame()
 	if err != nil {
 		return nil, err
 	}
 	if frame.GetOpCode() == HopeIM.OpClose {
+		HopeIM.ReleaseFrame(frame)
 		return nil, errors.New("remote side close the channel")
 	}
 	return frame, nil
@@ -143,23 +280,34 @@ func (c *Client) Read() (HopeIM.Frame, error) {
 
 func (c *Client) heartbealoop() error {
 	tick := time.NewTicker(c.options.Heartbeat)
-	for range tick.C {
+	defer tick.Stop()
+	for {
+		select {
+		case <-c.done:
+			return nil
+		case <-tick.C:
+		}
+		if c.reconnector != nil && c.reconnector.State() != HopeIM.ConnStateConnected {
+			continue
+		}
 		// 发送一个ping的心跳包给服务端
-		if err := c.ping(); err != nil {
+		gen, err := c.ping()
+		if err == nil {
+			continue
+		}
+		if c.reconnector == nil {
 			return err
 		}
+		c.reconnector.Disconnected(gen, err)
 	}
-	return nil
 }
 
-func (c *Client) ping() error {
+func (c *Client) ping() (uint64, error) {
 	logger.WithField("module", "tcp.client").Tracef("%s send ping to server", c.id)
 
-	err := c.conn.SetWriteDeadline(time.Now().Add(c.options.WriteWait))
-	if err != nil {
-		return err
-	}
-	return c.conn.WriteFrame(HopeIM.OpPing, nil)
+	c.Lock()
+	defer c.Unlock()
+	return c.gen, c.writeFrame(HopeIM.OpPing, nil)
 }
 
 // ID return id
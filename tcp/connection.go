@@ -3,15 +3,41 @@ package tcp
 import (
 	"io"
 	"net"
+	"sync"
 
 	"github.com/sjmshsh/HopeIM"
+	"github.com/sjmshsh/HopeIM/wire"
 	"github.com/sjmshsh/HopeIM/wire/endian"
 )
 
+// maxPooledPayload 超过这个大小的payload用完之后不放回缓冲池，避免长期占用大块内存
+const maxPooledPayload = 64 * 1024
+
+var framePool = sync.Pool{
+	New: func() interface{} {
+		return new(Frame)
+	},
+}
+
 // Frame Frame
 type Frame struct {
 	OpCode  HopeIM.OpCode
 	Payload []byte
+	// pooled 由ReadFrame从缓冲池中取出
+	pooled bool
+}
+
+// Release 把frame及payload归还到缓冲池，之后不能再访问payload
+func (f *Frame) Release() {
+	if !f.pooled {
+		return
+	}
+	f.pooled = false
+	if cap(f.Payload) > maxPooledPayload {
+		f.Payload = nil
+	}
+	f.Payload = f.Payload[:0]
+	framePool.Put(f)
 }
 
 // SetOpCode SetOpCode
@@ -37,29 +63,47 @@ func (f *Frame) GetPayload() []byte {
 // Conn Conn
 type TcpConn struct {
 	net.Conn
+	maxFrameSize int
+	// header 读取帧头的缓冲区，ReadFrame只在读循环中调用，不需要加锁
+	header [frameHeaderSize]byte
 }
 
 // NewConn NewConn
 func NewConn(conn net.Conn) *TcpConn {
 	return &TcpConn{
-		Conn: conn,
+		Conn:         conn,
+		maxFrameSize: wire.DefaultMaxFrameSize,
 	}
 }
 
+// SetMaxFrameSize 负载超过size的帧返回wire.LimitError，0表示不限制
+func (c *TcpConn) SetMaxFrameSize(size int) {
+	c.maxFrameSize = size
+}
+
 // ReadFrame ReadFrame
 func (c *TcpConn) ReadFrame() (HopeIM.Frame, error) {
-	opcode, err := endian.ReadUint8(c.Conn)
-	if err != nil {
+	// 一次读取opcode与长度，不再为每个字段分配内存
+	if _, err := io.ReadFull(c.Conn, c.header[:]); err != nil {
 		return nil, err
 	}
-	payload, err := endian.ReadBytes(c.Conn)
-	if err != nil {
+	size := endian.Default.Uint32(c.header[1:])
+	if c.maxFrameSize > 0 && uint64(size) > uint64(c.maxFrameSize) {
+		return nil, &wire.LimitError{Field: wire.LimitFrame, Size: int(size), Limit: c.maxFrameSize}
+	}
+	// payload读取到缓冲池中的frame，调用方处理完之后通过HopeIM.ReleaseFrame归还
+	frame := framePool.Get().(*Frame)
+	frame.pooled = true
+	frame.OpCode = HopeIM.OpCode(c.header[0])
+	if cap(frame.Payload) < int(size) {
+		frame.Payload = make([]byte, size)
+	}
+	frame.Payload = frame.Payload[:size]
+	if _, err := io.ReadFull(c.Conn, frame.Payload); err != nil {
+		frame.Release()
 		return nil, err
 	}
-	return &Frame{
-		OpCode:  HopeIM.OpCode(opcode),
-		Payload: payload,
-	}, nil
+	return frame, nil
 }
 
 // WriteFrame WriteFrame
@@ -72,13 +116,32 @@ func (c *TcpConn) Flush() error {
 	return nil
 }
 
-// WriteFrame write a frame to w
+// frameHeaderSize opcode(1) + payload length(4)
+const frameHeaderSize = 5
+
+// frameWriter 复用帧头与writev的缓冲区
+type frameWriter struct {
+	header [frameHeaderSize]byte
+	vec    [2][]byte
+	bufs   net.Buffers
+}
+
+var writerPool = sync.Pool{
+	New: func() interface{} {
+		return new(frameWriter)
+	},
+}
+
+// WriteFrame write a frame to w, the header and the payload are written together without copying the payload
 func WriteFrame(w io.Writer, code HopeIM.OpCode, payload []byte) error {
-	if err := endian.WriteUint8(w, uint8(code)); err != nil {
-		return err
-	}
-	if err := endian.WriteBytes(w, payload); err != nil {
-		return err
-	}
-	return nil
+	fw := writerPool.Get().(*frameWriter)
+	fw.header[0] = byte(code)
+	endian.Default.PutUint32(fw.header[1:], uint32(len(payload)))
+	fw.vec[0], fw.vec[1] = fw.header[:], payload
+	// net.Conn上使用writev，WriteTo会消费bufs，每次从vec重新生成
+	fw.bufs = fw.vec[:]
+	_, err := fw.bufs.WriteTo(w)
+	fw.vec[1] = nil
+	writerPool.Put(fw)
+	return err
 }
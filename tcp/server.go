@@ -2,6 +2,7 @@ package tcp
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"net"
 	"sync"
@@ -9,6 +10,7 @@ import (
 
 	"github.com/sjmshsh/HopeIM"
 	"github.com/sjmshsh/HopeIM/logger"
+	"github.com/sjmshsh/HopeIM/wire"
 
 	"github.com/segmentio/ksuid"
 )
@@ -18,6 +20,60 @@ type ServerOptions struct {
 	loginwait time.Duration //登录超时
 	readwait  time.Duration //读超时
 	writewait time.Duration //读超时
+	queue     HopeIM.WriteQueueOptions
+	reader    HopeIM.ReadDispatcher
+	drain     HopeIM.DrainOptions
+	admission HopeIM.Admission
+	tls       *tls.Config
+	// maxFrameSize 读取的帧的最大负载
+	maxFrameSize int
+}
+
+// ServerOption ServerOption
+type ServerOption func(opts *ServerOptions)
+
+// WithWriteQueue set the send queue of each channel
+func WithWriteQueue(queue HopeIM.WriteQueueOptions) ServerOption {
+	return func(opts *ServerOptions) {
+		opts.queue = queue
+	}
+}
+
+// WithReadDispatcher set how the messages read from channels are delivered to the MessageListener,
+// the server will close it on Shutdown if it implements interface{ Close() }
+func WithReadDispatcher(reader HopeIM.ReadDispatcher) ServerOption {
+	return func(opts *ServerOptions) {
+		opts.reader = reader
+	}
+}
+
+// WithDrain set how the channels are drained on Shutdown
+func WithDrain(drain HopeIM.DrainOptions) ServerOption {
+	return func(opts *ServerOptions) {
+		opts.drain = drain
+	}
+}
+
+// WithAdmission set the admission control which is checked before the Acceptor
+func WithAdmission(admission HopeIM.Admission) ServerOption {
+	return func(opts *ServerOptions) {
+		opts.admission = admission
+	}
+}
+
+// WithMaxFrameSize set the max payload size of frames read from channels, 0 means unlimited,
+// the channel is closed when a frame exceeds it
+func WithMaxFrameSize(size int) ServerOption {
+	return func(opts *ServerOptions) {
+		opts.maxFrameSize = size
+	}
+}
+
+// WithTLS serve with TLS, see HopeIM.NewServerTLSConfig
+func WithTLS(config *tls.Config) ServerOption {
+	return func(opts *ServerOptions) {
+		opts.tls = config
+	}
 }
 
 // Server is a websocket implement of the Server
@@ -34,17 +90,26 @@ type Server struct {
 }
 
 // NewServer NewServer
-func NewServer(listen string, service HopeIM.ServiceRegistration) HopeIM.Server {
+func NewServer(listen string, service HopeIM.ServiceRegistration, options ...ServerOption) HopeIM.Server {
+	opts := ServerOptions{
+		loginwait: HopeIM.DefaultLoginWait,
+		readwait:  HopeIM.DefaultReadWait,
+		writewait: time.Second * 10,
+		queue:     HopeIM.DefaultWriteQueueOptions(),
+		reader:    HopeIM.GoroutineDispatcher{},
+		drain:     HopeIM.DefaultDrainOptions(),
+
+		maxFrameSize: wire.DefaultMaxFrameSize,
+	}
+	for _, option := range options {
+		option(&opts)
+	}
 	return &Server{
 		listen:              listen,
 		ServiceRegistration: service,
-		ChannelMap:          HopeIM.NewChannels(100),
+		ChannelMap:          HopeIM.NewChannels(HopeIM.DefaultChannelShards),
 		quit:                HopeIM.NewEvent(),
-		options: ServerOptions{
-			loginwait: HopeIM.DefaultLoginWait,
-			readwait:  HopeIM.DefaultReadWait,
-			writewait: time.Second * 10,
-		},
+		options:             opts,
 	}
 }
 
@@ -67,18 +132,41 @@ func (s *Server) Start() error {
 	if err != nil {
 		return err
 	}
+	if s.options.tls != nil {
+		// 握手在第一次读写时进行，不会阻塞Accept
+		lst = tls.NewListener(lst, s.options.tls)
+	}
+	// Shutdown时关闭listener，停止接收新的连接
+	go func() {
+		<-s.quit.Done()
+		_ = lst.Close()
+	}()
 	log.Info("started")
 	for {
 		rawconn, err := lst.Accept()
 		if err != nil {
-			rawconn.Close()
+			if s.quit.HasFired() {
+				return fmt.Errorf("listen exited")
+			}
 			log.Warn(err)
 			continue
 		}
+		// 准入控制，拒绝时把原因通过OpClose发送给客户端
+		if s.options.admission != nil {
+			if err := s.options.admission.Admit(rawconn.RemoteAddr().String()); err != nil {
+				log.Debugf("reject %s: %v", rawconn.RemoteAddr(), err)
+				go reject(rawconn, err)
+				continue
+			}
+		}
 		go func(rawconn net.Conn) {
+			if s.options.admission != nil {
+				defer s.options.admission.Release(rawconn.RemoteAddr().String())
+			}
 			conn := NewConn(rawconn)
+			conn.SetMaxFrameSize(s.options.maxFrameSize)
 
-			id, err := s.Accept(conn, s.options.loginwait)
+			id, attrs, err := s.Accept(conn, s.options.loginwait)
 			if err != nil {
 				_ = conn.WriteFrame(HopeIM.OpClose, []byte(err.Error()))
 				conn.Close()
@@ -91,11 +179,16 @@ func (s *Server) Start() error {
 				return
 			}
 
-			channel := HopeIM.NewChannel(id, conn)
+			channel := HopeIM.NewChannelWithOptions(id, conn, s.options.queue)
 			channel.SetReadWait(s.options.readwait)
 			channel.SetWriteWait(s.options.writewait)
+			channel.SetReadDispatcher(s.options.reader)
+			channel.SetAttributes(HopeIM.ResolveAttributes(attrs, conn))
 
 			s.Add(channel)
+			if account := channel.Attributes().Account; account != "" {
+				s.Index(id, HopeIM.IndexAccount, account)
+			}
 
 			log.Info("accept ", channel)
 			err = channel.Readloop(s.MessageListener)
@@ -103,15 +196,9 @@ func (s *Server) Start() error {
 				log.Info(err)
 			}
 			s.Remove(channel.ID())
-			_ = s.Disconnect(channel.ID())
+			_ = s.Disconnect(channel)
 			channel.Close()
 		}(rawconn)
-
-		select {
-		case <-s.quit.Done():
-			return fmt.Errorf("listen exited")
-		default:
-		}
 	}
 
 }
@@ -126,20 +213,14 @@ func (s *Server) Shutdown(ctx context.Context) error {
 		defer func() {
 			log.Infoln("shutdown")
 		}()
-		// close channels
-		chanels := s.ChannelMap.All()
-		for _, ch := range chanels {
-			ch.Close()
-
-			select {
-			case <-ctx.Done():
-				return
-			default:
-				continue
-			}
-		}
-
+		// 1. 停止接收新的连接
+		s.quit.Fire()
+		// 2. 通知客户端重连，并分批关闭连接
+		HopeIM.Drain(ctx, s.ChannelMap, s.options.drain)
 	})
+	if closer, ok := s.options.reader.(interface{ Close() }); ok {
+		closer.Close()
+	}
 	return nil
 }
 
@@ -182,6 +263,14 @@ type defaultAcceptor struct {
 }
 
 // Accept defaultAcceptor
-func (a *defaultAcceptor) Accept(conn HopeIM.Conn, timeout time.Duration) (string, error) {
-	return ksuid.New().String(), nil
+func (a *defaultAcceptor) Accept(conn HopeIM.Conn, timeout time.Duration) (string, *HopeIM.Attributes, error) {
+	return ksuid.New().String(), nil, nil
+}
+
+// reject 发送拒绝的原因之后关闭连接。读写都设置了超时，
+// tls连接会在写入之前完成握手，不发送ClientHello的客户端最多占用一个goroutine一秒
+func reject(conn net.Conn, err error) {
+	_ = conn.SetDeadline(time.Now().Add(time.Second))
+	_ = WriteFrame(conn, HopeIM.OpClose, []byte(err.Error()))
+	_ = conn.Close()
 }
@@ -0,0 +1,163 @@
+package HopeIM
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sjmshsh/HopeIM/logger"
+)
+
+// TLSOptions 服务端的TLS配置
+type TLSOptions struct {
+	CertFile string
+	KeyFile  string
+	// ClientCAFile 不为空时使用它验证客户端证书
+	ClientCAFile string
+	// RequireClientCert 为true时客户端必须提供证书，否则只验证客户端提供的证书
+	RequireClientCert bool
+	// ReloadInterval 检查证书文件是否变化的最小间隔，为0时不重新加载
+	ReloadInterval time.Duration
+}
+
+// ClientTLSOptions 客户端的TLS配置
+type ClientTLSOptions struct {
+	// CAFile 验证服务端证书的CA，为空时使用系统的根证书
+	CAFile string
+	// CertFile KeyFile 客户端证书，服务端要求验证客户端时使用
+	CertFile string
+	KeyFile  string
+	// ServerName 为空时使用拨号地址中的host
+	ServerName         string
+	InsecureSkipVerify bool
+}
+
+// NewServerTLSConfig 创建服务端的tls.Config，证书文件变化之后会在新的握手中自动重新加载
+func NewServerTLSConfig(opts TLSOptions) (*tls.Config, error) {
+	reloader, err := NewCertReloader(opts.CertFile, opts.KeyFile, opts.ReloadInterval)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: reloader.GetCertificate,
+	}
+	if opts.ClientCAFile != "" {
+		pool, err := loadCertPool(opts.ClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.ClientCAs = pool
+		cfg.ClientAuth = tls.VerifyClientCertIfGiven
+		if opts.RequireClientCert {
+			cfg.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+	}
+	return cfg, nil
+}
+
+// NewClientTLSConfig 创建客户端的tls.Config
+func NewClientTLSConfig(opts ClientTLSOptions) (*tls.Config, error) {
+	cfg := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         opts.ServerName,
+		InsecureSkipVerify: opts.InsecureSkipVerify,
+	}
+	if opts.CAFile != "" {
+		pool, err := loadCertPool(opts.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.RootCAs = pool
+	}
+	if opts.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(opts.CertFile, opts.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
+
+// CertReloader 从文件中加载证书，文件的修改时间变化之后重新加载
+type CertReloader struct {
+	sync.Mutex
+	certFile string
+	keyFile  string
+	interval time.Duration
+	cert     *tls.Certificate
+	modTime  time.Time
+	checked  time.Time
+}
+
+// NewCertReloader NewCertReloader
+func NewCertReloader(certFile, keyFile string, interval time.Duration) (*CertReloader, error) {
+	r := &CertReloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+		interval: interval,
+	}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// GetCertificate 用于tls.Config.GetCertificate，重新加载失败时继续使用旧的证书
+func (r *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.Lock()
+	defer r.Unlock()
+	if r.interval > 0 && time.Since(r.checked) >= r.interval {
+		if modTime := r.lastModified(); modTime.After(r.modTime) {
+			if err := r.reload(); err != nil {
+				logger.WithField("module", "tls").Warnf("reload %s failed: %v", r.certFile, err)
+			} else {
+				logger.WithField("module", "tls").Infof("certificate %s reloaded", r.certFile)
+			}
+		}
+		r.checked = time.Now()
+	}
+	return r.cert, nil
+}
+
+func (r *CertReloader) reload() error {
+	modTime := r.lastModified()
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return err
+	}
+	r.cert = &cert
+	r.modTime = modTime
+	r.checked = time.Now()
+	return nil
+}
+
+func (r *CertReloader) lastModified() time.Time {
+	var modTime time.Time
+	for _, file := range []string{r.certFile, r.keyFile} {
+		info, err := os.Stat(file)
+		if err != nil {
+			continue
+		}
+		if info.ModTime().After(modTime) {
+			modTime = info.ModTime()
+		}
+	}
+	return modTime
+}
+
+func loadCertPool(file string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificate found in %s", file)
+	}
+	return pool, nil
+}
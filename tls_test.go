@@ -0,0 +1,149 @@
+package HopeIM
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+// issue 签发一个证书，parent为nil时生成自签名的CA
+func issue(t *testing.T, serial int64, name string, parent *testCert) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	tpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	signer, signerKey := tpl, key
+	if parent == nil {
+		tpl.IsCA = true
+		tpl.BasicConstraintsValid = true
+		tpl.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tpl, signer, &key.PublicKey, signerKey)
+	assert.Nil(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.Nil(t, err)
+	return &testCert{cert: cert, key: key}
+}
+
+func (c *testCert) write(t *testing.T, dir, name string) (string, string) {
+	certFile := filepath.Join(dir, name+".crt")
+	keyFile := filepath.Join(dir, name+".key")
+	der, err := x509.MarshalECPrivateKey(c.key)
+	assert.Nil(t, err)
+	assert.Nil(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.cert.Raw}), 0600))
+	assert.Nil(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), 0600))
+	return certFile, keyFile
+}
+
+func handshake(t *testing.T, server, client *tls.Config) (*tls.ConnectionState, error) {
+	lst, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer lst.Close()
+	errc := make(chan error, 1)
+	go func() {
+		conn, err := lst.Accept()
+		if err != nil {
+			errc <- err
+			return
+		}
+		defer conn.Close()
+		errc <- tls.Server(conn, server).Handshake()
+	}()
+	conn, err := tls.Dial("tcp", lst.Addr().String(), client)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	// TLS1.3中服务端在客户端握手完成之后才校验客户端证书
+	if err := <-errc; err != nil {
+		return nil, err
+	}
+	state := conn.ConnectionState()
+	return &state, nil
+}
+
+func TestTLS_ClientCert(t *testing.T) {
+	dir := t.TempDir()
+	ca := issue(t, 1, "ca", nil)
+	caFile, _ := ca.write(t, dir, "ca")
+	serverCert, serverKey := issue(t, 2, "gateway.local", ca).write(t, dir, "server")
+	clientCert, clientKey := issue(t, 3, "client", ca).write(t, dir, "client")
+
+	server, err := NewServerTLSConfig(TLSOptions{
+		CertFile:          serverCert,
+		KeyFile:           serverKey,
+		ClientCAFile:      caFile,
+		RequireClientCert: true,
+	})
+	assert.Nil(t, err)
+
+	client, err := NewClientTLSConfig(ClientTLSOptions{
+		CAFile:     caFile,
+		CertFile:   clientCert,
+		KeyFile:    clientKey,
+		ServerName: "gateway.local",
+	})
+	assert.Nil(t, err)
+	state, err := handshake(t, server, client)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), state.PeerCertificates[0].SerialNumber.Int64())
+
+	// 没有客户端证书时握手失败
+	client, _ = NewClientTLSConfig(ClientTLSOptions{CAFile: caFile, ServerName: "gateway.local"})
+	_, err = handshake(t, server, client)
+	assert.NotNil(t, err)
+}
+
+func TestTLS_Reload(t *testing.T) {
+	dir := t.TempDir()
+	ca := issue(t, 1, "ca", nil)
+	caFile, _ := ca.write(t, dir, "ca")
+	certFile, keyFile := issue(t, 2, "gateway.local", ca).write(t, dir, "server")
+
+	server, err := NewServerTLSConfig(TLSOptions{
+		CertFile:       certFile,
+		KeyFile:        keyFile,
+		ReloadInterval: time.Millisecond,
+	})
+	assert.Nil(t, err)
+	client, _ := NewClientTLSConfig(ClientTLSOptions{CAFile: caFile, ServerName: "gateway.local"})
+
+	state, err := handshake(t, server, client)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), state.PeerCertificates[0].SerialNumber.Int64())
+
+	// 替换证书文件，新的握手使用新的证书
+	issue(t, 4, "gateway.local", ca).write(t, dir, "server")
+	future := time.Now().Add(time.Minute)
+	assert.Nil(t, os.Chtimes(certFile, future, future))
+	time.Sleep(time.Millisecond * 5)
+
+	state, err = handshake(t, server, client)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(4), state.PeerCertificates[0].SerialNumber.Int64())
+}
@@ -1,6 +1,8 @@
 package websocket
 
 import (
+	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"net"
@@ -13,6 +15,8 @@ import (
 	"github.com/gobwas/ws/wsutil"
 	"github.com/sjmshsh/HopeIM"
 	"github.com/sjmshsh/HopeIM/logger"
+	"github.com/sjmshsh/HopeIM/wire"
+	"github.com/sjmshsh/HopeIM/wire/pkt"
 )
 
 // ClientOptions ClientOptions
@@ -20,6 +24,13 @@ type ClientOptions struct {
 	Heartbeat time.Duration //登录超时
 	ReadWait  time.Duration //读超时
 	WriteWait time.Duration //写超时
+	TLSConfig *tls.Config   //不为nil时使用TLS连接
+	// Compression 不为nil时在握手中请求permessage-deflate，服务端不支持时不压缩
+	Compression *CompressionOptions
+	// MaxFrameSize 读取的帧的最大负载，为0时使用wire.DefaultMaxFrameSize
+	MaxFrameSize int
+	// Reconnect 不为nil时连接断开之后自动重连，Read会等待重连完成之后继续读取
+	Reconnect *HopeIM.ReconnectOptions
 }
 
 // Client is a websocket implement of the terminal
@@ -29,30 +40,44 @@ type Client struct {
 	once    sync.Once
 	id      string
 	name    string
+	addr    string
 	conn    net.Conn
+	gen     uint64
 	state   int32
 	options ClientOptions
 	Meta    map[string]string
+	done    chan struct{}
+
+	// compression 握手协商成功之后不为nil，每次重连都会重新协商
+	compression *CompressionOptions
+	reconnector *HopeIM.Reconnector
+
+	reqonce   sync.Once
+	requester *HopeIM.Requester
 }
 
 // NewClient NewClient
-func NewClient(id, name string, opts ClientOptions) HopeIM.Client {
+func NewClient(id, name string, opts ClientOptions) HopeIM.RequestClient {
 	return NewClientWithProps(id, name, make(map[string]string), opts)
 }
 
-func NewClientWithProps(id, name string, meta map[string]string, opts ClientOptions) HopeIM.Client {
+func NewClientWithProps(id, name string, meta map[string]string, opts ClientOptions) HopeIM.RequestClient {
 	if opts.WriteWait == 0 {
 		opts.WriteWait = HopeIM.DefaultWriteWait
 	}
 	if opts.ReadWait == 0 {
 		opts.ReadWait = HopeIM.DefaultReadWait
 	}
+	if opts.MaxFrameSize == 0 {
+		opts.MaxFrameSize = wire.DefaultMaxFrameSize
+	}
 
 	cli := &Client{
 		id:      id,
 		name:    name,
 		options: opts,
 		Meta:    meta,
+		done:    make(chan struct{}),
 	}
 	return cli
 }
@@ -66,25 +91,23 @@ func (c *Client) Connect(addr string) error {
 	if !atomic.CompareAndSwapInt32(&c.state, 0, 1) {
 		return fmt.Errorf("client has connected")
 	}
+	c.addr = addr
+
 	// step 1 拨号及握手
-	conn, err := c.Dialer.DialAndHandshake(HopeIM.DialerContext{
-		Id:      c.id,
-		Name:    c.name,
-		Address: addr,
-		Timeout: HopeIM.DefaultLoginWait,
-	})
+	if c.options.Reconnect != nil {
+		c.reconnector = HopeIM.NewReconnector(*c.options.Reconnect, c.dial, c.write)
+		err = c.reconnector.Connect()
+	} else {
+		err = c.dial(1)
+	}
 	if err != nil {
 		atomic.CompareAndSwapInt32(&c.state, 1, 0)
 		return err
 	}
-	if conn == nil {
-		return fmt.Errorf("conn is nil")
-	}
-	c.conn = conn
 
 	if c.options.Heartbeat > 0 {
 		go func() {
-			err := c.heartbealoop(conn)
+			err := c.heartbealoop()
 			if err != nil {
 				logger.Error("heartbealoop stopped ", err)
 			}
@@ -93,6 +116,94 @@ func (c *Client) Connect(addr string) error {
 	return nil
 }
 
+// dial 拨号并握手，成功之后替换当前的连接，重连时同样调用它
+func (c *Client) dial(gen uint64) error {
+	conn, err := c.Dialer.DialAndHandshake(HopeIM.DialerContext{
+		Id:          c.id,
+		Name:        c.name,
+		Address:     c.addr,
+		Timeout:     HopeIM.DefaultLoginWait,
+		TLSConfig:   c.options.TLSConfig,
+		Compression: c.options.Compression != nil,
+	})
+	if err != nil {
+		return err
+	}
+	if conn == nil {
+		return fmt.Errorf("conn is nil")
+	}
+	var compression *CompressionOptions
+	if cc, ok := conn.(*compressedConn); ok {
+		conn = cc.Conn
+		compression = c.options.Compression
+	}
+
+	c.Lock()
+	defer c.Unlock()
+	select {
+	case <-c.done:
+		_ = conn.Close()
+		return HopeIM.ErrClientClosed
+	default:
+	}
+	if c.conn != nil {
+		_ = c.conn.Close()
+	}
+	c.conn = conn
+	c.compression = compression
+	c.gen = gen
+	return nil
+}
+
+func (c *Client) current() (net.Conn, *CompressionOptions, uint64) {
+	c.Lock()
+	defer c.Unlock()
+	return c.conn, c.compression, c.gen
+}
+
+// Request 发送一个请求，并等待Sequence相同的响应。
+// 调用之后读操作由内部的读循环接管，不能再直接调用Read
+func (c *Client) Request(ctx context.Context, p *pkt.LogicPkt) (*pkt.LogicPkt, error) {
+	return c.getRequester().Request(ctx, p)
+}
+
+// SetPushHandler 设置服务端推送消息的回调，同样会接管读操作
+func (c *Client) SetPushHandler(handler HopeIM.PushHandler) {
+	c.getRequester().SetPushHandler(handler)
+}
+
+func (c *Client) getRequester() *HopeIM.Requester {
+	c.reqonce.Do(func() {
+		c.requester = HopeIM.NewRequester(c)
+	})
+	return c.requester
+}
+
+// Dial 拨号并完成websocket握手，wss://地址使用ctx.TLSConfig。
+// ctx.Compression为true时请求permessage-deflate，协商成功后Client会自动压缩与解压消息
+func Dial(ctx HopeIM.DialerContext) (net.Conn, error) {
+	dialer := ws.Dialer{
+		Timeout:   ctx.Timeout,
+		TLSConfig: ctx.TLSConfig,
+	}
+	if ctx.Compression {
+		dialer.Extensions = compressionOffer()
+	}
+	conn, _, hs, err := dialer.Dial(context.TODO(), ctx.Address)
+	if err != nil {
+		return nil, err
+	}
+	if ctx.Compression && compressionAccepted(hs.Extensions) {
+		return &compressedConn{Conn: conn}, nil
+	}
+	return conn, nil
+}
+
+// compressedConn 标记连接协商了压缩
+type compressedConn struct {
+	net.Conn
+}
+
 // SetDialer 设置握手逻辑
 func (c *Client) SetDialer(dialer HopeIM.Dialer) {
 	c.Dialer = dialer
@@ -103,12 +214,45 @@ func (c *Client) Send(payload []byte) error {
 	if atomic.LoadInt32(&c.state) == 0 {
 		return fmt.Errorf("connection is nil")
 	}
+	for {
+		if c.reconnector != nil {
+			// 重连期间写入缓存
+			buffered, err := c.reconnector.Buffer(payload)
+			if buffered || err != nil {
+				return err
+			}
+		}
+		c.Lock()
+		gen := c.gen
+		err := c.writeMessage(payload)
+		c.Unlock()
+		if err == nil || c.reconnector == nil {
+			return err
+		}
+		c.reconnector.Disconnected(gen, err)
+	}
+}
+
+// write 在当前连接上写入，用于重连之后发送缓存的数据
+func (c *Client) write(payload []byte) error {
 	c.Lock()
 	defer c.Unlock()
+	return c.writeMessage(payload)
+}
+
+func (c *Client) writeMessage(payload []byte) error {
 	err := c.conn.SetWriteDeadline(time.Now().Add(c.options.WriteWait))
 	if err != nil {
 		return err
 	}
+	if c.compression != nil && len(payload) >= c.compression.Threshold {
+		frame, err := compressFrame(ws.NewFrame(ws.OpBinary, true, payload), c.compression)
+		if err != nil {
+			return err
+		}
+		// 压缩后的负载是新分配的，可以直接在原地加上MASK
+		return ws.WriteFrame(c.conn, ws.MaskFrameInPlace(frame))
+	}
 	// 客户端消息需要使用MASK
 	return wsutil.WriteClientMessage(c.conn, ws.OpBinary, payload)
 }
@@ -116,6 +260,12 @@ func (c *Client) Send(payload []byte) error {
 // Close 关闭
 func (c *Client) Close() {
 	c.once.Do(func() {
+		close(c.done)
+		if c.reconnector != nil {
+			c.reconnector.Close()
+		}
+		c.Lock()
+		defer c.Unlock()
 		if c.conn == nil {
 			return
 		}
@@ -127,46 +277,83 @@ func (c *Client) Close() {
 	})
 }
 
-// Read a frame ,this function is not safey for concurrent
+// Read a frame ,this function is not safey for concurrent.
+// 开启重连时连接断开之后等待重连完成再继续读取，只有在Close或者放弃重连之后才返回错误
 func (c *Client) Read() (HopeIM.Frame, error) {
-	if c.conn == nil {
-		return nil, errors.New("connection is nil")
+	for {
+		conn, compression, gen := c.current()
+		if conn == nil {
+			return nil, errors.New("connection is nil")
+		}
+		frame, err := c.readFrom(conn, compression)
+		if err == nil {
+			return frame, nil
+		}
+		if c.reconnector == nil {
+			return nil, err
+		}
+		c.reconnector.Disconnected(gen, err)
+		if err := c.reconnector.Wait(); err != nil {
+			return nil, err
+		}
 	}
+}
+
+func (c *Client) readFrom(conn net.Conn, compression *CompressionOptions) (HopeIM.Frame, error) {
 	if c.options.Heartbeat > 0 {
-		_ = c.conn.SetReadDeadline(time.Now().Add(c.options.ReadWait))
+		_ = conn.SetReadDeadline(time.Now().Add(c.options.ReadWait))
 	}
-	frame, err := ws.ReadFrame(c.conn)
+	frame, err := readFrame(conn, c.options.MaxFrameSize)
 	if err != nil {
 		return nil, err
 	}
 	if frame.Header.OpCode == ws.OpClose {
 		return nil, errors.New("remote side close the channel")
 	}
+	if compression != nil {
+		frame, err = decompressFrame(frame, c.options.MaxFrameSize)
+		if err != nil {
+			return nil, err
+		}
+	}
 	return &Frame{
 		raw: frame,
 	}, nil
 }
 
-func (c *Client) heartbealoop(conn net.Conn) error {
+func (c *Client) heartbealoop() error {
 	tick := time.NewTicker(c.options.Heartbeat)
-	for range tick.C {
+	defer tick.Stop()
+	for {
+		select {
+		case <-c.done:
+			return nil
+		case <-tick.C:
+		}
+		if c.reconnector != nil && c.reconnector.State() != HopeIM.ConnStateConnected {
+			continue
+		}
 		// 发送一个ping的心跳包给服务端
-		if err := c.ping(conn); err != nil {
+		gen, err := c.ping()
+		if err == nil {
+			continue
+		}
+		if c.reconnector == nil {
 			return err
 		}
+		c.reconnector.Disconnected(gen, err)
 	}
-	return nil
 }
 
-func (c *Client) ping(conn net.Conn) error {
+func (c *Client) ping() (uint64, error) {
 	c.Lock()
 	defer c.Unlock()
-	err := conn.SetWriteDeadline(time.Now().Add(c.options.WriteWait))
+	err := c.conn.SetWriteDeadline(time.Now().Add(c.options.WriteWait))
 	if err != nil {
-		return err
+		return c.gen, err
 	}
 	logger.Tracef("%s send ping to server", c.id)
-	return wsutil.WriteClientMessage(conn, ws.OpPing, nil)
+	return c.gen, wsutil.WriteClientMessage(c.conn, ws.OpPing, nil)
 }
 
 // ID return id
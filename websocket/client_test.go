@@ -0,0 +1,79 @@
+package websocket
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sjmshsh/HopeIM"
+	"github.com/sjmshsh/HopeIM/naming"
+	"github.com/stretchr/testify/assert"
+)
+
+type countDialer struct {
+	dials int32
+}
+
+func (d *countDialer) DialAndHandshake(ctx HopeIM.DialerContext) (net.Conn, error) {
+	atomic.AddInt32(&d.dials, 1)
+	return Dial(ctx)
+}
+
+func TestClient_Reconnect(t *testing.T) {
+	lst, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	addr := lst.Addr().String()
+	_ = lst.Close()
+
+	srv := NewServer(addr, &naming.DefaultService{Id: "test", Name: "test"})
+	srv.SetMessageListener(echoListener{})
+	srv.SetStateListener(echoListener{})
+	go func() {
+		_ = srv.Start()
+	}()
+	defer srv.Shutdown(context.Background())
+	for i := 0; i < 50; i++ {
+		if conn, err := net.Dial("tcp", addr); err == nil {
+			_ = conn.Close()
+			break
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	states := make(chan HopeIM.ConnState, 10)
+	cli := NewClient("test1", "client", ClientOptions{
+		Reconnect: &HopeIM.ReconnectOptions{
+			MinBackoff: time.Millisecond * 10,
+			MaxBackoff: time.Millisecond * 50,
+			BufferSize: 10,
+			OnStateChange: func(state HopeIM.ConnState, err error) {
+				states <- state
+			},
+		},
+	})
+	dialer := &countDialer{}
+	cli.SetDialer(dialer)
+	assert.Nil(t, cli.Connect("ws://"+addr))
+	defer cli.Close()
+	assert.Equal(t, HopeIM.ConnStateConnecting, <-states)
+	assert.Equal(t, HopeIM.ConnStateConnected, <-states)
+
+	// 断开底层连接，Read在重连之后继续读取
+	conn, _, _ := cli.(*Client).current()
+	_ = conn.Close()
+	go func() {
+		assert.Equal(t, HopeIM.ConnStateConnecting, <-states)
+		// 重连期间发送的消息在重连之后发出
+		assert.Nil(t, cli.Send([]byte("hello")))
+	}()
+	frame, err := cli.Read()
+	assert.Nil(t, err)
+	assert.Equal(t, "hello", string(frame.GetPayload()))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&dialer.dials))
+
+	cli.Close()
+	_, err = cli.Read()
+	assert.Equal(t, HopeIM.ErrClientClosed, err)
+}
@@ -0,0 +1,151 @@
+package websocket
+
+import (
+	"bytes"
+	"compress/flate"
+	"io"
+	"sync"
+
+	"github.com/gobwas/httphead"
+	"github.com/gobwas/ws"
+	"github.com/gobwas/ws/wsflate"
+	"github.com/sjmshsh/HopeIM/wire"
+)
+
+const (
+	// DefaultCompressionThreshold 小于这个长度的消息不压缩
+	DefaultCompressionThreshold = 512
+	// DefaultCompressionLevel 默认压缩级别
+	DefaultCompressionLevel = flate.BestSpeed
+)
+
+// CompressionOptions permessage-deflate压缩配置，
+// 双方都使用no_context_takeover，每条消息独立压缩，连接上不需要保留压缩的上下文
+type CompressionOptions struct {
+	// Threshold 负载长度达到Threshold的消息才压缩
+	Threshold int
+	// Level 压缩级别，取值范围与compress/flate相同，0表示DefaultCompressionLevel
+	Level int
+}
+
+// DefaultCompressionOptions DefaultCompressionOptions
+func DefaultCompressionOptions() CompressionOptions {
+	return CompressionOptions{
+		Threshold: DefaultCompressionThreshold,
+		Level:     DefaultCompressionLevel,
+	}
+}
+
+func (o CompressionOptions) level() int {
+	if o.Level == flate.NoCompression || o.Level < flate.HuffmanOnly || o.Level > flate.BestCompression {
+		return DefaultCompressionLevel
+	}
+	return o.Level
+}
+
+// 一个完整的deflate块之后需要补上的尾部，见RFC 7692 7.2.2
+var deflateTail = []byte{0x00, 0x00, 0xff, 0xff, 0x01, 0x00, 0x00, 0xff, 0xff}
+
+var (
+	// flate.Writer的创建开销很大，按照压缩级别复用
+	writerPools [flate.BestCompression - flate.HuffmanOnly + 1]sync.Pool
+	readerPool  sync.Pool
+)
+
+func compress(payload []byte, level int) ([]byte, error) {
+	var buf bytes.Buffer
+	pool := &writerPools[level-flate.HuffmanOnly]
+	fw, _ := pool.Get().(*flate.Writer)
+	if fw == nil {
+		fw, _ = flate.NewWriter(&buf, level)
+	} else {
+		fw.Reset(&buf)
+	}
+	defer pool.Put(fw)
+	if _, err := fw.Write(payload); err != nil {
+		return nil, err
+	}
+	if err := fw.Flush(); err != nil {
+		return nil, err
+	}
+	// 去掉Flush写入的 0x00 0x00 0xff 0xff
+	return bytes.TrimSuffix(buf.Bytes(), deflateTail[:4]), nil
+}
+
+// decompress 解压之后的长度超过maxSize时返回wire.LimitError，maxSize为0时不限制
+func decompress(payload []byte, maxSize int) ([]byte, error) {
+	src := io.MultiReader(bytes.NewReader(payload), bytes.NewReader(deflateTail))
+	fr, _ := readerPool.Get().(io.ReadCloser)
+	if fr == nil {
+		fr = flate.NewReader(src)
+	} else {
+		_ = fr.(flate.Resetter).Reset(src, nil)
+	}
+	defer readerPool.Put(fr)
+	if maxSize <= 0 {
+		return io.ReadAll(fr)
+	}
+	// 多读一个字节用来判断是否超过了限制
+	data, err := io.ReadAll(io.LimitReader(fr, int64(maxSize)+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxSize {
+		return nil, &wire.LimitError{Field: wire.LimitFrame, Size: len(data), Limit: maxSize}
+	}
+	return data, nil
+}
+
+// compressFrame 压缩数据帧，控制帧与小于Threshold的消息原样返回
+func compressFrame(f ws.Frame, opts *CompressionOptions) (ws.Frame, error) {
+	if opts == nil || f.Header.OpCode.IsControl() || len(f.Payload) < opts.Threshold {
+		return f, nil
+	}
+	payload, err := compress(f.Payload, opts.level())
+	if err != nil {
+		return f, err
+	}
+	f.Payload = payload
+	f.Header.Length = int64(len(payload))
+	f.Header, err = wsflate.SetBit(f.Header)
+	return f, err
+}
+
+// decompressFrame 解压设置了RSV1的数据帧，返回的帧已经去掉了掩码
+func decompressFrame(f ws.Frame, maxSize int) (ws.Frame, error) {
+	h, compressed, err := wsflate.UnsetBit(f.Header)
+	if err != nil || !compressed {
+		return f, err
+	}
+	if h.Masked {
+		ws.Cipher(f.Payload, h.Mask, 0)
+		h.Masked = false
+	}
+	payload, err := decompress(f.Payload, maxSize)
+	if err != nil {
+		return f, err
+	}
+	h.Length = int64(len(payload))
+	f.Header = h
+	f.Payload = payload
+	return f, nil
+}
+
+// compressionOffer 客户端握手时请求的扩展参数
+func compressionOffer() []httphead.Option {
+	return []httphead.Option{wsflate.DefaultParameters.Option()}
+}
+
+// compressionAccepted 判断服务端是否同意了permessage-deflate
+func compressionAccepted(extensions []httphead.Option) bool {
+	for _, opt := range extensions {
+		if !bytes.Equal(opt.Name, wsflate.ExtensionNameBytes) {
+			continue
+		}
+		var params wsflate.Parameters
+		if err := params.Parse(opt); err == nil {
+			return true
+		}
+	}
+	return false
+}
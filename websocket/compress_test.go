@@ -0,0 +1,118 @@
+package websocket
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/gobwas/ws"
+	"github.com/gobwas/ws/wsflate"
+	"github.com/sjmshsh/HopeIM"
+	"github.com/sjmshsh/HopeIM/naming"
+	"github.com/sjmshsh/HopeIM/wire"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompressFrame(t *testing.T) {
+	opts := &CompressionOptions{Threshold: 16}
+	payload := bytes.Repeat([]byte("hello HopeIM "), 100)
+
+	f, err := compressFrame(ws.NewFrame(ws.OpBinary, true, payload), opts)
+	assert.Nil(t, err)
+	assert.True(t, f.Header.Rsv1())
+	assert.Less(t, len(f.Payload), len(payload))
+
+	// 与wsflate的实现互通
+	got, err := wsflate.DecompressFrame(f)
+	assert.Nil(t, err)
+	assert.Equal(t, payload, got.Payload)
+
+	// RFC 7692 7.2.3.1 中压缩的"Hello"
+	f = ws.NewFrame(ws.OpText, true, []byte{0xf2, 0x48, 0xcd, 0xc9, 0xc9, 0x07, 0x00})
+	f.Header, _ = wsflate.SetBit(f.Header)
+	got, err = decompressFrame(ws.MaskFrameInPlace(f), 0)
+	assert.Nil(t, err)
+	assert.False(t, got.Header.Rsv1())
+	assert.False(t, got.Header.Masked)
+	assert.Equal(t, "Hello", string(got.Payload))
+
+	// 小于Threshold的消息与控制帧不压缩
+	f, _ = compressFrame(ws.NewFrame(ws.OpBinary, true, []byte("hi")), opts)
+	assert.False(t, f.Header.Rsv1())
+	f, _ = compressFrame(ws.NewFrame(ws.OpPing, true, payload), opts)
+	assert.False(t, f.Header.Rsv1())
+}
+
+type echoListener struct{}
+
+func (echoListener) Receive(ag HopeIM.Agent, payload []byte) {
+	_ = ag.Push(payload)
+}
+
+func (echoListener) Disconnect(HopeIM.Agent) error {
+	return nil
+}
+
+type rawDialer struct{}
+
+func (rawDialer) DialAndHandshake(ctx HopeIM.DialerContext) (net.Conn, error) {
+	return Dial(ctx)
+}
+
+func TestServer_Compression(t *testing.T) {
+	lst, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	addr := lst.Addr().String()
+	_ = lst.Close()
+
+	srv := NewServer(addr, &naming.DefaultService{Id: "test", Name: "test"},
+		WithCompression(CompressionOptions{Threshold: 16}))
+	srv.SetMessageListener(echoListener{})
+	srv.SetStateListener(echoListener{})
+	go func() {
+		_ = srv.Start()
+	}()
+	defer srv.Shutdown(context.Background())
+
+	payload := bytes.Repeat([]byte("hello HopeIM "), 100)
+	for _, compression := range []*CompressionOptions{{Threshold: 16}, nil} {
+		cli := NewClient("test1", "client", ClientOptions{Compression: compression})
+		cli.SetDialer(rawDialer{})
+		var err error
+		for i := 0; i < 50; i++ {
+			if err = cli.Connect("ws://" + addr); err == nil {
+				break
+			}
+			time.Sleep(time.Millisecond * 10)
+		}
+		assert.Nil(t, err)
+		// 不请求压缩的客户端同样可以正常通信
+		assert.Equal(t, compression != nil, cli.(*Client).compression != nil)
+
+		assert.Nil(t, cli.Send(payload))
+		frame, err := cli.Read()
+		assert.Nil(t, err)
+		assert.Equal(t, payload, frame.GetPayload())
+		cli.Close()
+	}
+}
+
+func TestReadFrame_Limit(t *testing.T) {
+	var lerr *wire.LimitError
+	buf := new(bytes.Buffer)
+	_ = ws.WriteHeader(buf, ws.Header{Fin: true, OpCode: ws.OpBinary, Length: 1 << 40})
+	_, err := readFrame(buf, 1024)
+	assert.True(t, errors.As(err, &lerr))
+	assert.Equal(t, wire.LimitFrame, lerr.Field)
+
+	// 解压之后超过限制
+	payload := bytes.Repeat([]byte("a"), 4096)
+	f, _ := compressFrame(ws.NewFrame(ws.OpBinary, true, payload), &CompressionOptions{})
+	assert.Less(t, len(f.Payload), 1024)
+	_, err = decompressFrame(f, 1024)
+	assert.True(t, errors.As(err, &lerr))
+	assert.Equal(t, 1024, lerr.Limit)
+}
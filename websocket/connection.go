@@ -1,10 +1,12 @@
 package websocket
 
 import (
+	"io"
 	"net"
 
 	"github.com/gobwas/ws"
 	"github.com/sjmshsh/HopeIM"
+	"github.com/sjmshsh/HopeIM/wire"
 )
 
 type Frame struct {
@@ -33,24 +35,66 @@ func (f *Frame) GetPayload() []byte {
 
 type WsConn struct {
 	net.Conn
+	compression  *CompressionOptions
+	maxFrameSize int
 }
 
 func NewConn(conn net.Conn) *WsConn {
 	return &WsConn{
-		Conn: conn,
+		Conn:         conn,
+		maxFrameSize: wire.DefaultMaxFrameSize,
 	}
 }
 
+// NewConnWithCompression 创建协商了permessage-deflate的连接
+func NewConnWithCompression(conn net.Conn, opts CompressionOptions) *WsConn {
+	return &WsConn{
+		Conn:         conn,
+		compression:  &opts,
+		maxFrameSize: wire.DefaultMaxFrameSize,
+	}
+}
+
+// SetMaxFrameSize 负载或者解压之后的负载超过size的帧返回wire.LimitError，0表示不限制
+func (c *WsConn) SetMaxFrameSize(size int) {
+	c.maxFrameSize = size
+}
+
 func (c *WsConn) ReadFrame() (HopeIM.Frame, error) {
-	f, err := ws.ReadFrame(c.Conn)
+	f, err := readFrame(c.Conn, c.maxFrameSize)
 	if err != nil {
 		return nil, err
 	}
+	if c.compression != nil {
+		f, err = decompressFrame(f, c.maxFrameSize)
+		if err != nil {
+			return nil, err
+		}
+	}
 	return &Frame{raw: f}, nil
 }
 
+// readFrame 与ws.ReadFrame相同，但是在分配内存之前检查负载的长度
+func readFrame(r io.Reader, maxSize int) (ws.Frame, error) {
+	h, err := ws.ReadHeader(r)
+	if err != nil {
+		return ws.Frame{}, err
+	}
+	if maxSize > 0 && h.Length > int64(maxSize) {
+		return ws.Frame{}, &wire.LimitError{Field: wire.LimitFrame, Size: int(h.Length), Limit: maxSize}
+	}
+	payload := make([]byte, h.Length)
+	if _, err = io.ReadFull(r, payload); err != nil {
+		return ws.Frame{}, err
+	}
+	return ws.Frame{Header: h, Payload: payload}, nil
+}
+
 func (c *WsConn) WriteFrame(code HopeIM.OpCode, payload []byte) error {
-	f := ws.NewFrame(ws.OpCode(code), true, payload)
+	f, err := compressFrame(ws.NewFrame(ws.OpCode(code), true, payload), c.compression)
+	if err != nil {
+		return err
+	}
 	return ws.WriteFrame(c.Conn, f)
 }
 
@@ -2,6 +2,7 @@ package websocket
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"net/http"
@@ -9,9 +10,11 @@ import (
 	"time"
 
 	"github.com/gobwas/ws"
+	"github.com/gobwas/ws/wsflate"
 	"github.com/segmentio/ksuid"
 	"github.com/sjmshsh/HopeIM"
 	"github.com/sjmshsh/HopeIM/logger"
+	"github.com/sjmshsh/HopeIM/wire"
 )
 
 // ServerOptions ServerOptions
@@ -19,6 +22,68 @@ type ServerOptions struct {
 	loginwait time.Duration //登录超时
 	readwait  time.Duration //读超时
 	writewait time.Duration //写超时
+	queue     HopeIM.WriteQueueOptions
+	reader    HopeIM.ReadDispatcher
+	drain     HopeIM.DrainOptions
+	admission HopeIM.Admission
+	tls       *tls.Config
+	compress  *CompressionOptions
+	// maxFrameSize 读取的帧的最大负载
+	maxFrameSize int
+}
+
+// ServerOption ServerOption
+type ServerOption func(opts *ServerOptions)
+
+// WithWriteQueue set the send queue of each channel
+func WithWriteQueue(queue HopeIM.WriteQueueOptions) ServerOption {
+	return func(opts *ServerOptions) {
+		opts.queue = queue
+	}
+}
+
+// WithReadDispatcher set how the messages read from channels are delivered to the MessageListener,
+// the server will close it on Shutdown if it implements interface{ Close() }
+func WithReadDispatcher(reader HopeIM.ReadDispatcher) ServerOption {
+	return func(opts *ServerOptions) {
+		opts.reader = reader
+	}
+}
+
+// WithDrain set how the channels are drained on Shutdown
+func WithDrain(drain HopeIM.DrainOptions) ServerOption {
+	return func(opts *ServerOptions) {
+		opts.drain = drain
+	}
+}
+
+// WithAdmission set the admission control which is checked before the Acceptor
+func WithAdmission(admission HopeIM.Admission) ServerOption {
+	return func(opts *ServerOptions) {
+		opts.admission = admission
+	}
+}
+
+// WithMaxFrameSize set the max payload size of frames read from channels, 0 means unlimited,
+// the channel is closed when a frame exceeds it
+func WithMaxFrameSize(size int) ServerOption {
+	return func(opts *ServerOptions) {
+		opts.maxFrameSize = size
+	}
+}
+
+// WithTLS serve with TLS, see HopeIM.NewServerTLSConfig
+func WithTLS(config *tls.Config) ServerOption {
+	return func(opts *ServerOptions) {
+		opts.tls = config
+	}
+}
+
+// WithCompression enable permessage-deflate, clients that don't negotiate it are served uncompressed
+func WithCompression(compression CompressionOptions) ServerOption {
+	return func(opts *ServerOptions) {
+		opts.compress = &compression
+	}
 }
 
 // Server is a websocket implement of the Server
@@ -31,18 +96,29 @@ type Server struct {
 	HopeIM.StateListener
 	once    sync.Once
 	options ServerOptions
+	srv     *http.Server
 }
 
 // NewServer NewServer
-func NewServer(listen string, service HopeIM.ServiceRegistration) HopeIM.Server {
+func NewServer(listen string, service HopeIM.ServiceRegistration, options ...ServerOption) HopeIM.Server {
+	opts := ServerOptions{
+		loginwait: HopeIM.DefaultLoginWait,
+		readwait:  HopeIM.DefaultReadWait,
+		writewait: HopeIM.DefaultWriteWait,
+		queue:     HopeIM.DefaultWriteQueueOptions(),
+		reader:    HopeIM.GoroutineDispatcher{},
+		drain:     HopeIM.DefaultDrainOptions(),
+
+		maxFrameSize: wire.DefaultMaxFrameSize,
+	}
+	for _, option := range options {
+		option(&opts)
+	}
 	return &Server{
 		listen:              listen,
 		ServiceRegistration: service,
-		options: ServerOptions{
-			loginwait: HopeIM.DefaultLoginWait,
-			readwait:  HopeIM.DefaultReadWait,
-			writewait: HopeIM.DefaultWriteWait,
-		},
+		options:             opts,
+		srv:                 &http.Server{Addr: listen},
 	}
 }
 
@@ -62,12 +138,32 @@ func (s *Server) Start() error {
 		return fmt.Errorf("StateListener is nil")
 	}
 	if s.ChannelMap == nil {
-		s.ChannelMap = HopeIM.NewChannels(100)
+		s.ChannelMap = HopeIM.NewChannels(HopeIM.DefaultChannelShards)
 	}
 
 	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		// step 1
-		rawconn, _, _, err := ws.UpgradeHTTP(r, w)
+		// step 0 准入控制，在升级协议之前拒绝
+		admitted := false
+		if s.options.admission != nil {
+			if err := s.options.admission.Admit(r.RemoteAddr); err != nil {
+				resp(w, admissionStatus(err), err.Error())
+				return
+			}
+			admitted = true
+			defer func() {
+				if admitted {
+					s.options.admission.Release(r.RemoteAddr)
+				}
+			}()
+		}
+		// step 1 升级协议，同时协商压缩扩展
+		var ext wsflate.Extension
+		upgrader := ws.HTTPUpgrader{}
+		if s.options.compress != nil {
+			ext.Parameters = wsflate.DefaultParameters
+			upgrader.Negotiate = ext.Negotiate
+		}
+		rawconn, _, _, err := upgrader.Upgrade(r, w)
 		if err != nil {
 			resp(w, http.StatusBadRequest, err.Error())
 			return
@@ -75,9 +171,13 @@ func (s *Server) Start() error {
 
 		// step 2 包装conn
 		conn := NewConn(rawconn)
+		if _, accepted := ext.Accepted(); accepted {
+			conn = NewConnWithCompression(rawconn, *s.options.compress)
+		}
+		conn.SetMaxFrameSize(s.options.maxFrameSize)
 
 		// step 3
-		id, err := s.Accept(conn, s.options.loginwait)
+		id, attrs, err := s.Accept(conn, s.options.loginwait)
 		if err != nil {
 			_ = conn.WriteFrame(HopeIM.OpClose, []byte(err.Error()))
 			conn.Close()
@@ -90,12 +190,23 @@ func (s *Server) Start() error {
 			return
 		}
 		// step 4
-		channel := HopeIM.NewChannel(id, conn)
+		channel := HopeIM.NewChannelWithOptions(id, conn, s.options.queue)
 		channel.SetWriteWait(s.options.writewait)
 		channel.SetReadWait(s.options.readwait)
+		channel.SetReadDispatcher(s.options.reader)
+		channel.SetAttributes(HopeIM.ResolveAttributes(attrs, conn))
 		s.Add(channel)
+		if account := channel.Attributes().Account; account != "" {
+			s.Index(id, HopeIM.IndexAccount, account)
+		}
 
+		// 连接关闭时再释放准入控制的计数
+		release := admitted
+		admitted = false
 		go func(ch HopeIM.Channel) {
+			if release {
+				defer s.options.admission.Release(r.RemoteAddr)
+			}
 			// step 5
 			err := ch.Readloop(s.MessageListener)
 			if err != nil {
@@ -103,7 +214,7 @@ func (s *Server) Start() error {
 			}
 			// step 6
 			s.Remove(ch.ID())
-			err = s.Disconnect(ch.ID())
+			err = s.Disconnect(ch)
 			if err != nil {
 				log.Warn(err)
 			}
@@ -112,7 +223,18 @@ func (s *Server) Start() error {
 
 	})
 	log.Infoln("started")
-	return http.ListenAndServe(s.listen, mux)
+	s.srv.Handler = mux
+	var err error
+	if s.options.tls != nil {
+		s.srv.TLSConfig = s.options.tls
+		err = s.srv.ListenAndServeTLS("", "")
+	} else {
+		err = s.srv.ListenAndServe()
+	}
+	if err == http.ErrServerClosed {
+		return fmt.Errorf("listen exited")
+	}
+	return err
 }
 
 // Shutdown Shutdown
@@ -125,20 +247,16 @@ func (s *Server) Shutdown(ctx context.Context) error {
 		defer func() {
 			log.Infoln("shutdown")
 		}()
-		// close channels
-		chanels := s.ChannelMap.All()
-		for _, ch := range chanels {
-			ch.Close()
-
-			select {
-			case <-ctx.Done():
-				return
-			default:
-				continue
-			}
+		// 1. 停止接收新的连接
+		_ = s.srv.Close()
+		// 2. 通知客户端重连，并分批关闭连接
+		if s.ChannelMap != nil {
+			HopeIM.Drain(ctx, s.ChannelMap, s.options.drain)
 		}
-
 	})
+	if closer, ok := s.options.reader.(interface{ Close() }); ok {
+		closer.Close()
+	}
 	return nil
 }
 
@@ -185,10 +303,21 @@ func resp(w http.ResponseWriter, code int, body string) {
 	logger.Warnf("response with code:%d %s", code, body)
 }
 
+func admissionStatus(err error) int {
+	switch err {
+	case HopeIM.ErrAddressDenied:
+		return http.StatusForbidden
+	case HopeIM.ErrTooManyConnectionsPerIP, HopeIM.ErrAcceptRateLimited:
+		return http.StatusTooManyRequests
+	default:
+		return http.StatusServiceUnavailable
+	}
+}
+
 type defaultAcceptor struct {
 }
 
 // Accept defaultAcceptor
-func (a *defaultAcceptor) Accept(conn HopeIM.Conn, timeout time.Duration) (string, error) {
-	return ksuid.New().String(), nil
+func (a *defaultAcceptor) Accept(conn HopeIM.Conn, timeout time.Duration) (string, *HopeIM.Attributes, error) {
+	return ksuid.New().String(), nil, nil
 }
@@ -12,12 +12,27 @@ const (
 	// login
 	CommandLoginSignIn  = "login.signin"
 	CommandLoginSignOut = "login.signout"
+	CommandLoginTouch   = "login.touch"
+
+	// gateway
+	CommandGatewayReconnect = "gateway.reconnect"
 
 	// chat
 	CommandChatUserTalk  = "chat.user.talk"
 	CommandChatGroupTalk = "chat.group.talk"
 	CommandChatTalkAck   = "chat.talk.ack"
 
+	// 信令，只推送给在线的接收方，不保存
+	CommandChatUserSignal  = "chat.user.signal"
+	CommandChatGroupSignal = "chat.group.signal"
+
+	// 在线状态
+	CommandPresenceQuery     = "chat.presence.query"
+	CommandPresenceSubscribe = "chat.presence.subscribe"
+	CommandPresenceSet       = "chat.presence.set"
+	// CommandPresenceNotify 推送给订阅者的在线状态变化
+	CommandPresenceNotify = "chat.presence.notify"
+
 	// 离线
 	CommandOfflineIndex   = "chat.offline.index"
 	CommandOfflineContent = "chat.offline.content"
@@ -38,6 +53,37 @@ const (
 	// 消息接收方，这是一个列表，也就是一个消息可以推送给多个用户
 	// 由于没有设置多设备登录，因此一个用户就是一个channel
 	MetaDestChannels = "dest.channels"
+
+	// MetaFromGateway 网关自己发出的消息，值为网关的ServiceID，比如会话续期。
+	// 网关会删除客户端消息中的这个meta，逻辑服务依此判断消息不是客户端伪造的
+	MetaFromGateway = "from.gateway"
+
+	// MetaRecvTime 网关收到客户端消息的时间(UnixNano)，由网关设置
+	MetaRecvTime = "gateway.recv"
+
+	// MetaExpiresAt 推送的有效期(UnixNano)，网关丢弃已经过期的推送，比如信令
+	MetaExpiresAt = "expires.at"
+)
+
+// Device 登录的设备类型
+const (
+	DeviceIOS     = "ios"
+	DeviceAndroid = "android"
+	DeviceWeb     = "web"
+	DevicePC      = "pc"
+)
+
+// Presence 账号的在线状态，away与busy是用户设置的自定义状态，只有在线时才生效
+const (
+	PresenceOnline  = "online"
+	PresenceOffline = "offline"
+	PresenceAway    = "away"
+	PresenceBusy    = "busy"
+)
+
+// Signal 常用的信令类型，客户端也可以使用自定义的类型
+const (
+	SignalTyping = "typing"
 )
 
 type Protocol string
@@ -72,6 +118,10 @@ const (
 	OfflineSyncIndexCount     = 2000                //单次同步消息索引的数量
 	OfflineMessageExpiresIn   = 15                  // 离线消息过期时间
 	MessageMaxCountPerPage    = 200                 // 同步消息内容时每页的最大数据
+	MessageDedupExpiresIn     = time.Minute * 10    // 客户端消息ID去重的时间窗口
+	MessageDedupPendingIn     = time.Second * 30    // 消息保存完成之前去重记录的过期时间
+	PresenceMaxAccounts       = 200                 // 单次查询或者订阅在线状态的最大账号数
+	SignalMaxBodySize         = 1024                // 信令消息体的最大长度
 )
 
 const (
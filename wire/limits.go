@@ -0,0 +1,88 @@
+package wire
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/sjmshsh/HopeIM/wire/endian"
+)
+
+// 默认的大小限制，避免对端通过长度前缀让我们分配过多的内存
+const (
+	DefaultMaxFrameSize  = 4 << 20
+	DefaultMaxHeaderSize = 256 << 10
+	DefaultMaxBodySize   = 4 << 20
+	DefaultMaxMetaCount  = 64
+)
+
+// LimitError中的字段
+const (
+	LimitFrame  = "frame"
+	LimitHeader = "header"
+	LimitBody   = "body"
+	LimitMeta   = "meta"
+)
+
+// ErrLimitExceeded 可以通过errors.Is判断一个错误是否是LimitError
+var ErrLimitExceeded = errors.New("err:limit exceeded")
+
+// LimitError 解码时超过了大小限制
+type LimitError struct {
+	// Field frame,header,body或者meta
+	Field string
+	// Size 对端声明的长度或者数量
+	Size  int
+	Limit int
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("err:%s size %d exceeds the limit %d", e.Field, e.Size, e.Limit)
+}
+
+// Is errors.Is(err, ErrLimitExceeded)
+func (e *LimitError) Is(target error) bool {
+	return target == ErrLimitExceeded
+}
+
+// Limits 解码时的大小限制，值为0表示不限制
+type Limits struct {
+	// MaxFrameSize 一个帧的负载大小
+	MaxFrameSize int
+	// MaxHeaderSize LogicPkt中header编码之后的大小
+	MaxHeaderSize int
+	// MaxBodySize LogicPkt与BasicPkt的body大小
+	MaxBodySize int
+	// MaxMetaCount LogicPkt中meta的数量
+	MaxMetaCount int
+}
+
+// DefaultLimits DefaultLimits
+func DefaultLimits() Limits {
+	return Limits{
+		MaxFrameSize:  DefaultMaxFrameSize,
+		MaxHeaderSize: DefaultMaxHeaderSize,
+		MaxBodySize:   DefaultMaxBodySize,
+		MaxMetaCount:  DefaultMaxMetaCount,
+	}
+}
+
+// CheckLimit size超过limit时返回LimitError，limit为0时不限制
+func CheckLimit(field string, size, limit int) error {
+	if limit > 0 && size > limit {
+		return &LimitError{Field: field, Size: size, Limit: limit}
+	}
+	return nil
+}
+
+// ReadBytes 与endian.ReadBytes相同，但是在分配内存之前检查长度前缀
+func ReadBytes(r io.Reader, field string, limit int) ([]byte, error) {
+	size, err := endian.ReadUint32(r)
+	if err != nil {
+		return nil, err
+	}
+	if limit > 0 && uint64(size) > uint64(limit) {
+		return nil, &LimitError{Field: field, Size: int(size), Limit: limit}
+	}
+	return endian.ReadFixedBytes(int(size), r)
+}
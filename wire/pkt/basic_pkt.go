@@ -1,6 +1,7 @@
 package pkt
 
 import (
+	"github.com/sjmshsh/HopeIM/wire"
 	"github.com/sjmshsh/HopeIM/wire/endian"
 	"io"
 )
@@ -17,7 +18,13 @@ type BasicPkt struct {
 	Body   []byte
 }
 
+// Decode decode with the wire.DefaultLimits
 func (p *BasicPkt) Decode(r io.Reader) error {
+	return p.DecodeWithLimits(r, wire.DefaultLimits())
+}
+
+// DecodeWithLimits return a *wire.LimitError if the body exceeds the limits
+func (p *BasicPkt) DecodeWithLimits(r io.Reader, limits wire.Limits) error {
 	var err error
 	if p.Code, err = endian.ReadUint16(r); err != nil {
 		return err
@@ -25,6 +32,9 @@ func (p *BasicPkt) Decode(r io.Reader) error {
 	if p.Length, err = endian.ReadUint16(r); err != nil {
 		return err
 	}
+	if err = wire.CheckLimit(wire.LimitBody, int(p.Length), limits.MaxBodySize); err != nil {
+		return err
+	}
 	if p.Length > 0 {
 		if p.Body, err = endian.ReadFixedBytes(int(p.Length), r); err != nil {
 			return err
@@ -47,3 +57,28 @@ func (p *BasicPkt) Encode(w io.Writer) error {
 	}
 	return nil
 }
+
+// Size return the length of the packet encoded by Marshal, including the magic
+func (p *BasicPkt) Size() int {
+	size := len(wire.MagicBasicPkt) + 4
+	if p.Length > 0 {
+		size += len(p.Body)
+	}
+	return size
+}
+
+// MarshalTo encode the packet with the magic into buf
+func (p *BasicPkt) MarshalTo(buf []byte) (int, error) {
+	size := p.Size()
+	if len(buf) < size {
+		return 0, io.ErrShortBuffer
+	}
+	n := copy(buf, wire.MagicBasicPkt[:])
+	endian.Default.PutUint16(buf[n:], p.Code)
+	endian.Default.PutUint16(buf[n+2:], p.Length)
+	n += 4
+	if p.Length > 0 {
+		n += copy(buf[n:], p.Body)
+	}
+	return n, nil
+}
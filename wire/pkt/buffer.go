@@ -0,0 +1,49 @@
+package pkt
+
+import "sync"
+
+// maxPooledBufferSize 超过这个大小的buffer不放回池中，避免池中长期持有大块内存
+const maxPooledBufferSize = 64 << 10
+
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		b := make([]byte, 0, 512)
+		return &b
+	},
+}
+
+// Buffer 从池中分配的编码结果
+type Buffer struct {
+	b *[]byte
+}
+
+// Bytes 编码结果，Release之后不能再使用
+func (b *Buffer) Bytes() []byte {
+	return *b.b
+}
+
+// Release 放回池中
+func (b *Buffer) Release() {
+	if b.b == nil {
+		return
+	}
+	if cap(*b.b) <= maxPooledBufferSize {
+		*b.b = (*b.b)[:0]
+		bufferPool.Put(b.b)
+	}
+	b.b = nil
+}
+
+// MarshalBuffer 把packet编码到池化的Buffer中，适用于同步写入连接之后就不再使用编码结果的场景，
+// 例如Client.Send；写入Channel发送队列的数据不能使用它
+func MarshalBuffer(p Packet) Buffer {
+	size := p.Size()
+	b := bufferPool.Get().(*[]byte)
+	if cap(*b) < size {
+		*b = make([]byte, size)
+	}
+	*b = (*b)[:size]
+	n, _ := p.MarshalTo(*b)
+	*b = (*b)[:n]
+	return Buffer{b: b}
+}
@@ -0,0 +1,70 @@
+package pkt
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+
+	"github.com/golang/protobuf/proto"
+)
+
+// ErrUnknownContentType 没有注册的ContentType
+var ErrUnknownContentType = errors.New("err:unknown content type")
+
+// Codec 消息体的编解码器
+type Codec interface {
+	Marshal(val proto.Message) ([]byte, error)
+	Unmarshal(data []byte, val proto.Message) error
+}
+
+// JSONCodec 兼容web客户端的json编码
+type JSONCodec struct{}
+
+// Marshal Marshal
+func (JSONCodec) Marshal(val proto.Message) ([]byte, error) {
+	return json.Marshal(val)
+}
+
+// Unmarshal Unmarshal
+func (JSONCodec) Unmarshal(data []byte, val proto.Message) error {
+	return json.Unmarshal(data, val)
+}
+
+// ProtobufCodec 原生客户端使用的protobuf编码
+type ProtobufCodec struct{}
+
+// Marshal Marshal
+func (ProtobufCodec) Marshal(val proto.Message) ([]byte, error) {
+	return proto.Marshal(val)
+}
+
+// Unmarshal Unmarshal
+func (ProtobufCodec) Unmarshal(data []byte, val proto.Message) error {
+	return proto.Unmarshal(data, val)
+}
+
+var (
+	codecMu sync.RWMutex
+	codecs  = map[ContentType]Codec{
+		ContentType_Json:     JSONCodec{},
+		ContentType_Protobuf: ProtobufCodec{},
+	}
+)
+
+// RegisterCodec 注册或者替换一个ContentType的编解码器
+func RegisterCodec(contentType ContentType, codec Codec) {
+	codecMu.Lock()
+	defer codecMu.Unlock()
+	codecs[contentType] = codec
+}
+
+// GetCodec 返回ContentType对应的编解码器
+func GetCodec(contentType ContentType) (Codec, error) {
+	codecMu.RLock()
+	defer codecMu.RUnlock()
+	codec, ok := codecs[contentType]
+	if !ok {
+		return nil, ErrUnknownContentType
+	}
+	return codec, nil
+}
@@ -0,0 +1,33 @@
+package pkt
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogicPkt_Body(t *testing.T) {
+	for _, contentType := range []ContentType{ContentType_Json, ContentType_Protobuf} {
+		p := New("chat.user.talk", WithContentType(contentType)).WriteBody(&MessageReq{Type: 1, Body: "hello"})
+
+		// 编码方式随着header一起传输
+		got, err := MustReadLogicPkt(bytes.NewReader(Marshal(p)))
+		assert.Nil(t, err)
+		assert.Equal(t, contentType, got.ContentType)
+		var req MessageReq
+		assert.Nil(t, got.ReadBody(&req))
+		assert.Equal(t, "hello", req.Body)
+
+		// 响应使用请求的编码
+		assert.Equal(t, contentType, NewFrom(&got.Header).ContentType)
+	}
+	p := New("chat.user.talk", WithContentType(ContentType_Json)).WriteBody(&MessageReq{Body: "hello"})
+	assert.Equal(t, `{"body":"hello"}`, p.StringBody())
+
+	// 未知的编码
+	p = New("chat.user.talk", WithContentType(ContentType(9))).WriteBody(&MessageReq{Body: "hello"})
+	assert.Equal(t, ContentType_Json, p.ContentType)
+	p.ContentType = ContentType(9)
+	assert.Equal(t, ErrUnknownContentType, p.ReadBody(&MessageReq{}))
+}
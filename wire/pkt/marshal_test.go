@@ -0,0 +1,83 @@
+package pkt
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sjmshsh/HopeIM/wire"
+	"github.com/stretchr/testify/assert"
+)
+
+// legacyMarshal 之前基于反射与bytes.Buffer的实现，用于对比
+func legacyMarshal(p Packet) []byte {
+	buf := new(bytes.Buffer)
+	kind := reflect.TypeOf(p).Elem()
+	if kind.AssignableTo(reflect.TypeOf(LogicPkt{})) {
+		_, _ = buf.Write(wire.MagicLogicPkt[:])
+	} else if kind.AssignableTo(reflect.TypeOf(BasicPkt{})) {
+		_, _ = buf.Write(wire.MagicBasicPkt[:])
+	}
+	_ = p.Encode(buf)
+	return buf.Bytes()
+}
+
+func pushPacket() *LogicPkt {
+	channels := make([]string, 100)
+	for i := range channels {
+		channels[i] = fmt.Sprintf("gate01_account%03d_%d", i, i)
+	}
+	p := New("chat.group.talk", WithChannel("gate01_sender_1"), WithDest("group1"))
+	p.Flag = Flag_Push
+	p.AddStringMeta(wire.MetaDestServer, "gate01")
+	p.AddStringMeta(wire.MetaDestChannels, strings.Join(channels, ","))
+	p.WriteBody(&MessagePush{Type: 1, Body: strings.Repeat("hello", 50), SendTime: 1})
+	return p
+}
+
+func TestMarshal(t *testing.T) {
+	packets := []Packet{
+		pushPacket(),
+		New("login.signin"),
+		&BasicPkt{Code: CodePing},
+		&BasicPkt{Code: CodePong, Length: 2, Body: []byte{1, 2}},
+	}
+	for _, p := range packets {
+		expected := legacyMarshal(p)
+		assert.Equal(t, expected, Marshal(p))
+		assert.Equal(t, len(expected), p.Size())
+
+		buf := MarshalBuffer(p)
+		assert.Equal(t, expected, buf.Bytes())
+		buf.Release()
+
+		_, err := p.MarshalTo(make([]byte, p.Size()-1))
+		assert.Equal(t, io.ErrShortBuffer, err)
+	}
+}
+
+func BenchmarkMarshal(b *testing.B) {
+	p := pushPacket()
+	b.Run("legacy", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = legacyMarshal(p)
+		}
+	})
+	b.Run("Marshal", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = Marshal(p)
+		}
+	})
+	b.Run("MarshalBuffer", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			buf := MarshalBuffer(p)
+			buf.Release()
+		}
+	})
+}
@@ -1,11 +1,11 @@
 package pkt
 
 import (
-	"encoding/json"
 	"fmt"
 	"github.com/golang/protobuf/proto"
 	"github.com/sjmshsh/HopeIM/wire"
 	"github.com/sjmshsh/HopeIM/wire/endian"
+	protov2 "google.golang.org/protobuf/proto"
 	"io"
 	"strconv"
 	"strings"
@@ -40,6 +40,13 @@ func WithChannel(channelID string) HeaderOption {
 	}
 }
 
+// WithContentType set the codec of body
+func WithContentType(contentType ContentType) HeaderOption {
+	return func(h *Header) {
+		h.ContentType = contentType
+	}
+}
+
 // WithDest WithDest
 func WithDest(dest string) HeaderOption {
 	return func(h *Header) {
@@ -64,25 +71,35 @@ func New(command string, options ...HeaderOption) *LogicPkt {
 func NewFrom(header *Header) *LogicPkt {
 	pkt := &LogicPkt{}
 	pkt.Header = Header{
-		Command:   header.Command,
-		Sequence:  header.Sequence,
-		ChannelId: header.ChannelId,
-		Status:    header.Status,
-		Dest:      header.Dest,
+		Command:     header.Command,
+		Sequence:    header.Sequence,
+		ChannelId:   header.ChannelId,
+		Status:      header.Status,
+		Dest:        header.Dest,
+		ContentType: header.ContentType,
 	}
 	return pkt
 }
 
+// Decode decode with the wire.DefaultLimits
 func (p *LogicPkt) Decode(r io.Reader) error {
-	headerBytes, err := endian.ReadBytes(r)
+	return p.DecodeWithLimits(r, wire.DefaultLimits())
+}
+
+// DecodeWithLimits return a *wire.LimitError if the header, body or meta count exceeds the limits
+func (p *LogicPkt) DecodeWithLimits(r io.Reader, limits wire.Limits) error {
+	headerBytes, err := wire.ReadBytes(r, wire.LimitHeader, limits.MaxHeaderSize)
 	if err != nil {
 		return err
 	}
 	if err := proto.Unmarshal(headerBytes, &p.Header); err != nil {
 		return err
 	}
+	if err := wire.CheckLimit(wire.LimitMeta, len(p.Meta), limits.MaxMetaCount); err != nil {
+		return err
+	}
 	// read body
-	p.Body, err = endian.ReadBytes(r)
+	p.Body, err = wire.ReadBytes(r, wire.LimitBody, limits.MaxBodySize)
 	if err != nil {
 		return err
 	}
@@ -103,17 +120,56 @@ func (p *LogicPkt) Encode(w io.Writer) error {
 	return nil
 }
 
-// ReadBody val must be a pointer
+// Size return the length of the packet encoded by Marshal, including the magic
+func (p *LogicPkt) Size() int {
+	return len(wire.MagicLogicPkt) + 4 + protov2.Size(&p.Header) + 4 + len(p.Body)
+}
+
+// MarshalTo encode the packet with the magic into buf, the header is marshaled in place without a temporary buffer
+func (p *LogicPkt) MarshalTo(buf []byte) (int, error) {
+	hsize := protov2.Size(&p.Header)
+	size := len(wire.MagicLogicPkt) + 4 + hsize + 4 + len(p.Body)
+	if len(buf) < size {
+		return 0, io.ErrShortBuffer
+	}
+	n := copy(buf, wire.MagicLogicPkt[:])
+	endian.Default.PutUint32(buf[n:], uint32(hsize))
+	n += 4
+	// Size已经缓存了header的长度
+	header, err := protov2.MarshalOptions{UseCachedSize: true}.MarshalAppend(buf[n:n], &p.Header)
+	if err != nil {
+		return 0, err
+	}
+	if len(header) != hsize {
+		return 0, fmt.Errorf("header size changed while marshaling")
+	}
+	n += hsize
+	endian.Default.PutUint32(buf[n:], uint32(len(p.Body)))
+	n += 4
+	n += copy(buf[n:], p.Body)
+	return n, nil
+}
+
+// ReadBody val must be a pointer, the body is decoded by the codec of ContentType
 func (p *LogicPkt) ReadBody(val proto.Message) error {
-	return json.Unmarshal(p.Body, val)
+	codec, err := GetCodec(p.ContentType)
+	if err != nil {
+		return err
+	}
+	return codec.Unmarshal(p.Body, val)
 }
 
-// WritePb WritePb
+// WriteBody encode the body by the codec of ContentType, an unknown ContentType falls back to json
 func (p *LogicPkt) WriteBody(val proto.Message) *LogicPkt {
 	if val == nil {
 		return p
 	}
-	p.Body, _ = json.Marshal(val)
+	codec, err := GetCodec(p.ContentType)
+	if err != nil {
+		p.ContentType = ContentType_Json
+		codec = JSONCodec{}
+	}
+	p.Body, _ = codec.Marshal(val)
 	return p
 }
 
@@ -164,15 +220,13 @@ func (p *LogicPkt) GetMeta(key string) (interface{}, bool) {
 	return nil, false
 }
 
-// DelMeta DelMeta
+// DelMeta 删除所有key相同的meta
 func (p *LogicPkt) DelMeta(key string) {
-	for i, m := range p.Meta {
-		if m.Key == key {
-			length := len(p.Meta)
-			if i < length-1 {
-				copy(p.Meta[i:], p.Meta[i+1:])
-			}
-			p.Meta = p.Meta[:length-1]
+	metas := p.Meta[:0]
+	for _, m := range p.Meta {
+		if m.Key != key {
+			metas = append(metas, m)
 		}
 	}
+	p.Meta = metas
 }
@@ -1,16 +1,18 @@
 package pkt
 
 import (
-	"bytes"
 	"fmt"
 	"github.com/sjmshsh/HopeIM/wire"
 	"io"
-	"reflect"
 )
 
 type Packet interface {
 	Decode(r io.Reader) error
 	Encode(w io.Writer) error
+	// Size 包含魔数在内的编码长度
+	Size() int
+	// MarshalTo 把包含魔数的编码结果写入buf，buf的长度不能小于Size
+	MarshalTo(buf []byte) (int, error)
 }
 
 func MustReadLogicPkt(r io.Reader) (*LogicPkt, error) {
@@ -35,7 +37,13 @@ func MustReadBasicPkt(r io.Reader) (*BasicPkt, error) {
 	return nil, fmt.Errorf("packet is not a basic packet")
 }
 
+// Read read a LogicPkt or BasicPkt with the wire.DefaultLimits
 func Read(r io.Reader) (interface{}, error) {
+	return ReadWithLimits(r, wire.DefaultLimits())
+}
+
+// ReadWithLimits read a LogicPkt or BasicPkt, return a *wire.LimitError if the packet exceeds the limits
+func ReadWithLimits(r io.Reader, limits wire.Limits) (interface{}, error) {
 	magic := wire.Magic{}
 	_, err := io.ReadFull(r, magic[:])
 	if err != nil {
@@ -44,13 +52,13 @@ func Read(r io.Reader) (interface{}, error) {
 	switch magic {
 	case wire.MagicLogicPkt:
 		p := new(LogicPkt)
-		if err := p.Decode(r); err != nil {
+		if err := p.DecodeWithLimits(r, limits); err != nil {
 			return nil, err
 		}
 		return p, nil
 	case wire.MagicBasicPkt:
 		p := new(BasicPkt)
-		if err := p.Decode(r); err != nil {
+		if err := p.DecodeWithLimits(r, limits); err != nil {
 			return nil, err
 		}
 		return p, err
@@ -59,16 +67,9 @@ func Read(r io.Reader) (interface{}, error) {
 	}
 }
 
+// Marshal 编码一个包含魔数的packet，只分配一次内存
 func Marshal(p Packet) []byte {
-	buf := new(bytes.Buffer)
-	kind := reflect.TypeOf(p).Elem()
-
-	// 写入魔数
-	if kind.AssignableTo(reflect.TypeOf(LogicPkt{})) {
-		_, _ = buf.Write(wire.MagicLogicPkt[:])
-	} else if kind.AssignableTo(reflect.TypeOf(BasicPkt{})) {
-		_, _ = buf.Write(wire.MagicBasicPkt[:])
-	}
-	_ = p.Encode(buf)
-	return buf.Bytes()
+	buf := make([]byte, p.Size())
+	n, _ := p.MarshalTo(buf)
+	return buf[:n]
 }
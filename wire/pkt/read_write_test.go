@@ -0,0 +1,67 @@
+package pkt
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/sjmshsh/HopeIM/wire"
+	"github.com/sjmshsh/HopeIM/wire/endian"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadWithLimits(t *testing.T) {
+	limits := wire.Limits{MaxHeaderSize: 64, MaxBodySize: 16, MaxMetaCount: 2}
+
+	p := New("chat.user.talk")
+	p.Body = []byte("hello")
+	_, err := ReadWithLimits(bytes.NewReader(Marshal(p)), limits)
+	assert.Nil(t, err)
+
+	var lerr *wire.LimitError
+	p.Body = bytes.Repeat([]byte("a"), 17)
+	_, err = ReadWithLimits(bytes.NewReader(Marshal(p)), limits)
+	assert.True(t, errors.As(err, &lerr))
+	assert.Equal(t, wire.LimitBody, lerr.Field)
+
+	p.Body = nil
+	p.Dest = string(bytes.Repeat([]byte("a"), 64))
+	_, err = ReadWithLimits(bytes.NewReader(Marshal(p)), limits)
+	assert.True(t, errors.As(err, &lerr))
+	assert.Equal(t, wire.LimitHeader, lerr.Field)
+
+	p.Dest = ""
+	p.AddStringMeta("a", "1")
+	p.AddStringMeta("b", "2")
+	p.AddStringMeta("c", "3")
+	_, err = ReadWithLimits(bytes.NewReader(Marshal(p)), limits)
+	assert.True(t, errors.As(err, &lerr))
+	assert.Equal(t, wire.LimitMeta, lerr.Field)
+	assert.Equal(t, 3, lerr.Size)
+
+	bp := &BasicPkt{Code: CodePing, Length: 17, Body: bytes.Repeat([]byte("a"), 17)}
+	_, err = ReadWithLimits(bytes.NewReader(Marshal(bp)), limits)
+	assert.True(t, errors.Is(err, wire.ErrLimitExceeded))
+
+	// 伪造的长度前缀在分配内存之前就被拒绝
+	buf := new(bytes.Buffer)
+	buf.Write(wire.MagicLogicPkt[:])
+	_ = endian.WriteUint32(buf, 0xffffffff)
+	_, err = Read(buf)
+	assert.True(t, errors.As(err, &lerr))
+	assert.Equal(t, wire.DefaultMaxHeaderSize, lerr.Limit)
+}
+
+func TestLogicPkt_DelMeta(t *testing.T) {
+	p := New("chat.user.talk")
+	p.AddStringMeta(wire.MetaFromGateway, "gate01")
+	p.AddStringMeta("a", "1")
+	p.AddStringMeta(wire.MetaFromGateway, "gate02")
+	// 重复的key同样被删除，客户端不能用多个meta绕过
+	p.DelMeta(wire.MetaFromGateway)
+	_, ok := p.GetMeta(wire.MetaFromGateway)
+	assert.False(t, ok)
+	v, ok := p.GetMeta("a")
+	assert.True(t, ok)
+	assert.Equal(t, "1", v)
+}